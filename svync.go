@@ -1,68 +1,332 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/nvnieuwk/svync/svync_api"
 	cli "github.com/urfave/cli/v2"
 )
 
 func main() {
+	defer svync_api.ExitOnFatal()
+
 	app := &cli.App{
 		Name:            "svync",
 		Usage:           "A tool to standardize VCF files from structural variant callers",
 		HideHelpCommand: true,
 		Version:         "0.2.0",
-		Flags: []cli.Flag{
-			&cli.BoolFlag{
-				Name:     "nodate",
-				Aliases:  []string{"nd"},
-				Usage:    "Don't add the current date to the output VCF header",
-				Category: "Optional",
-			},
-			&cli.StringFlag{
-				Name:     "output",
-				Aliases:  []string{"o"},
-				Usage:    "The location to the output VCF file, defaults to stdout",
-				Category: "Optional",
-			},
-			// TODO re-add this when conversion is implemented
-			// &cli.BoolFlag{
-			// 	Name:     "to-breakpoint",
-			// 	Aliases:  []string{"tb"},
-			// 	Usage:    "Convert pairs of breakends to a single breakpoint variant. WARNING: this will cause some loss of data.",
-			// 	Category: "Optional",
-			// },
-			&cli.BoolFlag{
-				Name:     "mute-warnings",
-				Aliases:  []string{"mw"},
-				Usage:    "Mute all warnings.",
-				Category: "Optional",
-			},
+		Flags: append(append(outputFlags(), standardizeFlags()...),
 			&cli.StringFlag{
 				Name:     "config",
 				Aliases:  []string{"c"},
 				Usage:    "Configuration file (YAML) used for standardizing the VCF",
-				Required: true,
 				Category: "Required",
 			},
 			&cli.StringFlag{
 				Name:     "input",
 				Aliases:  []string{"i"},
 				Usage:    "The input VCF file to standardize",
-				Required: true,
 				Category: "Required",
 			},
-		},
+		),
 		Action: func(Cctx *cli.Context) error {
+			// The required flags are checked here, so they aren't required for the subcommands
+			if missing := missingFlags(Cctx, "config", "input"); len(missing) > 0 {
+				cli.ShowAppHelp(Cctx)
+				return fmt.Errorf("Required flags \"%s\" not set", strings.Join(missing, "\", \""))
+			}
 			config := svync_api.ReadConfig(Cctx)
 			svync_api.Execute(Cctx, config)
 			return nil
 		},
+		Commands: []*cli.Command{
+			{
+				Name:      "merge",
+				Usage:     "Merge the standardized VCF files of multiple callers into a consensus callset",
+				ArgsUsage: "<input.vcf> <input.vcf> [input.vcf...]",
+				Flags: append(outputFlags(),
+					&cli.StringSliceFlag{
+						Name:     "names",
+						Usage:    "The names of the callers in the order of the input files, defaults to the names of the input files",
+						Category: "Optional",
+					},
+					&cli.Int64Flag{
+						Name:     "distance",
+						Aliases:  []string{"d"},
+						Usage:    "The maximum distance between the breakpoints of variants that are merged",
+						Value:    1000,
+						Category: "Optional",
+					},
+					&cli.Float64Flag{
+						Name:     "overlap",
+						Usage:    "The minimum reciprocal overlap of deletions, duplications, inversions and copy number variants that are merged (0-1)",
+						Category: "Optional",
+					},
+					&cli.Float64Flag{
+						Name:     "size-similarity",
+						Usage:    "The minimum ratio between the lengths of variants that are merged (0-1)",
+						Category: "Optional",
+					},
+					&cli.IntFlag{
+						Name:     "min-support",
+						Usage:    "The minimum number of callers that support a variant",
+						Value:    1,
+						Category: "Optional",
+					},
+				),
+				Action: func(Cctx *cli.Context) error {
+					svync_api.Merge(Cctx)
+					return nil
+				},
+			},
+			{
+				Name:  "compare",
+				Usage: "Compare a standardized callset to a truth set",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "truth",
+						Aliases:  []string{"t"},
+						Usage:    "The VCF file of the truth set",
+						Required: true,
+						Category: "Required",
+					},
+					&cli.StringFlag{
+						Name:     "calls",
+						Usage:    "The VCF file of the calls to compare to the truth set",
+						Required: true,
+						Category: "Required",
+					},
+					&cli.StringFlag{
+						Name:     "prefix",
+						Aliases:  []string{"p"},
+						Usage:    "The prefix of the output files (<prefix>.tp.vcf, <prefix>.fp.vcf, <prefix>.fn.vcf and <prefix>.summary.json)",
+						Value:    "compare",
+						Category: "Optional",
+					},
+					&cli.Int64Flag{
+						Name:     "distance",
+						Aliases:  []string{"d"},
+						Usage:    "The maximum distance between the breakpoints of a call and a variant of the truth set",
+						Value:    500,
+						Category: "Optional",
+					},
+					&cli.Float64Flag{
+						Name:     "overlap",
+						Usage:    "The minimum reciprocal overlap of a call and a variant of the truth set (0-1)",
+						Category: "Optional",
+					},
+					&cli.Float64Flag{
+						Name:     "size-similarity",
+						Usage:    "The minimum ratio between the lengths of a call and a variant of the truth set (0-1)",
+						Value:    0.7,
+						Category: "Optional",
+					},
+					&cli.BoolFlag{
+						Name:     "nodate",
+						Aliases:  []string{"nd"},
+						Usage:    "Don't add the current date to the output VCF headers",
+						Category: "Optional",
+					},
+				},
+				Action: func(Cctx *cli.Context) error {
+					svync_api.Compare(Cctx)
+					return nil
+				},
+			},
+			{
+				Name:      "combine",
+				Usage:     "Combine the standardized VCF files of multiple samples into one multi-sample VCF file",
+				ArgsUsage: "<input.vcf> <input.vcf> [input.vcf...]",
+				Flags: append(outputFlags(),
+					&cli.Int64Flag{
+						Name:     "distance",
+						Aliases:  []string{"d"},
+						Usage:    "The maximum distance between the breakpoints of variants that are combined into one record",
+						Category: "Optional",
+					},
+					&cli.Float64Flag{
+						Name:     "overlap",
+						Usage:    "The minimum reciprocal overlap of deletions, duplications, inversions and copy number variants that are combined into one record (0-1)",
+						Category: "Optional",
+					},
+					&cli.Float64Flag{
+						Name:     "size-similarity",
+						Usage:    "The minimum ratio between the lengths of variants that are combined into one record (0-1)",
+						Category: "Optional",
+					},
+				),
+				Action: func(Cctx *cli.Context) error {
+					svync_api.Combine(Cctx)
+					return nil
+				},
+			},
+			{
+				Name:  "batch",
+				Usage: "Standardize all files in a manifest concurrently",
+				Flags: append(append(formatFlags(), standardizeFlags()...),
+					&cli.StringFlag{
+						Name:     "manifest",
+						Aliases:  []string{"m"},
+						Usage:    "A tab-separated file with the input file, the config file or preset and the output file of every file to standardize",
+						Required: true,
+						Category: "Required",
+					},
+					&cli.StringFlag{
+						Name:     "presets",
+						Aliases:  []string{"p"},
+						Usage:    "A directory with config files that can be used as presets in the manifest (e.g. delly for delly.yaml)",
+						Category: "Optional",
+					},
+					&cli.IntFlag{
+						Name:     "threads",
+						Aliases:  []string{"t"},
+						Usage:    "The number of files that are standardized at the same time",
+						Value:    4,
+						Category: "Optional",
+					},
+				),
+				Action: func(Cctx *cli.Context) error {
+					return svync_api.Batch(Cctx)
+				},
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
 		log.New(os.Stderr, "", 0).Fatal(err)
 	}
 }
+
+// The flags used by all commands that write an output file
+func outputFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "output",
+			Aliases:  []string{"o"},
+			Usage:    "The location to the output VCF file, defaults to stdout. Files ending in .gz are compressed with BGZF",
+			Category: "Optional",
+		},
+	}, formatFlags()...)
+}
+
+// The flags that set the format of the output files
+func formatFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:     "nodate",
+			Aliases:  []string{"nd"},
+			Usage:    "Don't add the current date to the output VCF header",
+			Category: "Optional",
+		},
+		&cli.StringFlag{
+			Name:     "output-format",
+			Aliases:  []string{"of"},
+			Usage:    "The format of the output file (vcf, bcf, bedpe, jsonl or tsv), defaults to the extension of the output file or vcf",
+			Category: "Optional",
+		},
+		&cli.StringSliceFlag{
+			Name:     "columns",
+			Usage:    "The columns of the TSV output or the extra INFO and FORMAT columns of the BEDPE output (e.g. CHROM,POS,INFO/SVLEN,FORMAT/GT)",
+			Category: "Optional",
+		},
+		&cli.BoolFlag{
+			Name:     "mute-warnings",
+			Aliases:  []string{"mw"},
+			Usage:    "Mute all warnings.",
+			Category: "Optional",
+		},
+	}
+}
+
+// The flags used to standardize the input files
+func standardizeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "vcf-version",
+			Aliases:  []string{"vv"},
+			Usage:    "The VCF version of the output (4.2, 4.3 or 4.4)",
+			Value:    "4.2",
+			Category: "Optional",
+		},
+		// TODO re-add this when conversion is implemented
+		// &cli.BoolFlag{
+		// 	Name:     "to-breakpoint",
+		// 	Aliases:  []string{"tb"},
+		// 	Usage:    "Convert pairs of breakends to a single breakpoint variant. WARNING: this will cause some loss of data.",
+		// 	Category: "Optional",
+		// },
+		&cli.BoolFlag{
+			Name:     "two-pass",
+			Aliases:  []string{"tp"},
+			Usage:    "Read the input VCF twice to gather file statistics that can be used with $STATS variables",
+			Category: "Optional",
+		},
+		&cli.StringFlag{
+			Name:     "rename-chrs",
+			Aliases:  []string{"rc"},
+			Usage:    "A tab-separated file with two columns (old and new name) used to rename the chromosomes",
+			Category: "Optional",
+		},
+		&cli.StringFlag{
+			Name:     "reference",
+			Aliases:  []string{"r"},
+			Usage:    "A reference FASTA file (with a .fai index) used to fill in and validate the REF bases",
+			Category: "Optional",
+		},
+		&cli.BoolFlag{
+			Name:     "strict-reference",
+			Aliases:  []string{"sr"},
+			Usage:    "Fail when the REF of a variant doesn't match the reference FASTA file instead of giving a warning",
+			Category: "Optional",
+		},
+		&cli.Int64Flag{
+			Name:     "to-symbolic",
+			Aliases:  []string{"ts"},
+			Usage:    "Convert sequence-resolved deletions and insertions of at least this size to symbolic alleles",
+			Category: "Optional",
+		},
+		&cli.Int64Flag{
+			Name:     "to-literal",
+			Aliases:  []string{"tl"},
+			Usage:    "Convert symbolic deletions and insertions up to this size to sequence-resolved alleles, needs --reference",
+			Category: "Optional",
+		},
+		&cli.StringFlag{
+			Name:     "normalize",
+			Aliases:  []string{"n"},
+			Usage:    "Shift the breakpoints of deletions, duplications and insertions to the leftmost (left) or rightmost (right) position within their microhomology, needs --reference",
+			Category: "Optional",
+		},
+		&cli.BoolFlag{
+			Name:     "sort",
+			Aliases:  []string{"s"},
+			Usage:    "Sort the output by the order of the contigs in the header (or natural order) and by position",
+			Category: "Optional",
+		},
+		&cli.IntFlag{
+			Name:     "sort-buffer",
+			Usage:    "The maximum number of variants kept in memory while sorting, more variants are sorted using temporary files",
+			Value:    100000,
+			Category: "Optional",
+		},
+		&cli.StringFlag{
+			Name:     "split-by",
+			Usage:    "Write a separate output file for every SVTYPE (svtype), chromosome (chrom) or sample (sample), the output path needs a {svtype}, {chrom} or {sample} placeholder",
+			Category: "Optional",
+		},
+	}
+}
+
+// Get the flags that aren't set
+func missingFlags(Cctx *cli.Context, names ...string) []string {
+	missing := []string{}
+	for _, name := range names {
+		if !Cctx.IsSet(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
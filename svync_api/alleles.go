@@ -0,0 +1,95 @@
+package svync_api
+
+import (
+	"fmt"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// Check if an allele is a sequence of bases (not symbolic, a breakend, missing or multi-allelic)
+func isLiteralAllele(allele string) bool {
+	return allele != "" && strings.Trim(strings.ToUpper(allele), "ACGTN") == ""
+}
+
+// Convert the sequence-resolved alleles of a deletion or insertion to a symbolic allele
+// This is only done when the length difference between REF and ALT is at least the threshold
+// The inserted sequence is kept in the SVINSSEQ INFO field
+func (variant *Variant) toSymbolic(threshold int64) {
+	ref := variant.Ref
+	alt := variant.Alt
+	if !isLiteralAllele(ref) || !isLiteralAllele(alt) || !strings.EqualFold(ref[:1], alt[:1]) {
+		return
+	}
+
+	switch {
+	case int64(len(ref)-len(alt)) >= threshold && len(alt) == 1:
+		svlen := len(ref) - 1
+		variant.Ref = ref[:1]
+		variant.Alt = "<DEL>"
+		variant.Info["SVTYPE"] = []string{"DEL"}
+		variant.Info["END"] = []string{fmt.Sprint(variant.Pos + int64(svlen))}
+		variant.Info["SVLEN"] = []string{fmt.Sprint(-svlen)}
+	case int64(len(alt)-len(ref)) >= threshold && len(ref) == 1:
+		variant.Alt = "<INS>"
+		variant.Info["SVTYPE"] = []string{"INS"}
+		variant.Info["END"] = []string{fmt.Sprint(variant.Pos)}
+		variant.Info["SVLEN"] = []string{fmt.Sprint(len(alt) - 1)}
+		variant.Info["SVINSSEQ"] = []string{alt[1:]}
+	}
+}
+
+// Convert the symbolic alleles of a standardized deletion or insertion to sequence-resolved alleles
+// This is only done for variants up to the maximum size. Deletions are expanded using the reference
+// and insertions using the sequence in the SVINSSEQ INFO field
+func (config *Config) toLiteral(variant *Variant, maxSize int64, Cctx *cli.Context) {
+	logger := newLogger()
+
+	keys := variant.altKeys()
+	if len(keys) == 0 || !strings.HasPrefix(variant.Alt, "<") {
+		return
+	}
+	svtype := keys[len(keys)-1]
+
+	contig, ok := config.reference.contigName(variant.Chromosome, config)
+	if !ok {
+		return
+	}
+
+	switch svtype {
+	case "DEL":
+		end, err := infoInt(variant, "END")
+		if err != nil || end-variant.Pos > maxSize || end <= variant.Pos {
+			return
+		}
+		bases, err := config.reference.sequence(contig, variant.Pos, end)
+		if err != nil {
+			if !Cctx.Bool("mute-warnings") {
+				logger.Printf("Failed to convert the variant with ID %s to literal alleles: %v", variant.Id, err)
+			}
+			return
+		}
+		variant.Ref = bases
+		variant.Alt = bases[:1]
+	case "INS":
+		insertion, ok := variant.Info["SVINSSEQ"]
+		if !ok || len(insertion) != 1 || !isLiteralAllele(insertion[0]) {
+			if !Cctx.Bool("mute-warnings") {
+				logger.Printf("The insertion with ID %s has no inserted sequence in the SVINSSEQ INFO field, keeping the symbolic allele", variant.Id)
+			}
+			return
+		}
+		if int64(len(insertion[0])) > maxSize {
+			return
+		}
+		base, err := config.reference.sequence(contig, variant.Pos, variant.Pos)
+		if err != nil {
+			if !Cctx.Bool("mute-warnings") {
+				logger.Printf("Failed to convert the variant with ID %s to literal alleles: %v", variant.Id, err)
+			}
+			return
+		}
+		variant.Ref = base
+		variant.Alt = base + insertion[0]
+	}
+}
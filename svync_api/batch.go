@@ -0,0 +1,221 @@
+package svync_api
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// A struct representing a row of the manifest of the batch command
+type batchJob struct {
+	// The input file, the config file or preset and the output file
+	Input  string
+	Config string
+	Output string
+
+	// The path to the config file, resolved from the config file or preset
+	configPath string
+
+	// The error of the job, nil when the job succeeded
+	err error
+
+	// The time it took to run the job
+	duration time.Duration
+}
+
+// A struct representing the contents of a config file used by the jobs
+type batchConfig struct {
+	data []byte
+	err  error
+}
+
+// Standardize all files in the manifest concurrently and write a table with the result of every file
+// Returns an error when at least one file failed
+func Batch(Cctx *cli.Context) error {
+	logger := newLogger()
+
+	threads := Cctx.Int("threads")
+	if threads < 1 {
+		logger.Fatalf("The number of threads (--threads) should be at least 1")
+	}
+
+	jobs := readManifest(Cctx.String("manifest"))
+
+	// Every config file is only read once
+	configs := map[string]*batchConfig{}
+	for _, job := range jobs {
+		if job.err != nil {
+			continue
+		}
+		path, err := resolveConfig(job.Config, Cctx.String("presets"))
+		if err != nil {
+			job.err = err
+			continue
+		}
+		job.configPath = path
+		if _, ok := configs[path]; !ok {
+			data, err := os.ReadFile(path)
+			configs[path] = &batchConfig{data: data, err: err}
+		}
+	}
+
+	queue := make(chan *batchJob)
+	wait := sync.WaitGroup{}
+	for thread := 0; thread < threads; thread++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for job := range queue {
+				job.run(Cctx, configs[job.configPath])
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if job.err == nil {
+			queue <- job
+		}
+	}
+	close(queue)
+	wait.Wait()
+
+	failed := 0
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "INPUT\tCONFIG\tOUTPUT\tSTATUS\tTIME\tERROR")
+	for _, job := range jobs {
+		status, message := "OK", ""
+		if job.err != nil {
+			failed++
+			status, message = "FAILED", job.err.Error()
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", job.Input, job.Config, job.Output, status, job.duration.Round(time.Millisecond), message)
+	}
+	table.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(jobs))
+	}
+	return nil
+}
+
+// Read the rows of the manifest, a tab-separated file with the columns input, config (or preset) and output
+// Empty lines, lines starting with # and a header line starting with "input" are skipped
+// Invalid rows are returned as failed jobs, so the other rows can still be standardized
+func readManifest(file string) []*batchJob {
+	logger := newLogger()
+
+	manifest, err := os.Open(file)
+	if err != nil {
+		logger.Fatalf("Failed to open the manifest: %v", err)
+	}
+	defer manifest.Close()
+
+	jobs := []*batchJob{}
+	outputs := map[string]int{}
+	scanner := bufio.NewScanner(manifest)
+	line := 0
+	for scanner.Scan() {
+		line++
+		row := strings.TrimSpace(scanner.Text())
+		if row == "" || strings.HasPrefix(row, "#") {
+			continue
+		}
+		columns := strings.Split(row, "\t")
+		if strings.EqualFold(columns[0], "input") {
+			continue
+		}
+		// Missing columns are shown as empty columns in the table
+		found := len(columns)
+		columns = append(columns, "", "")
+		job := &batchJob{
+			Input:  strings.TrimSpace(columns[0]),
+			Config: strings.TrimSpace(columns[1]),
+			Output: strings.TrimSpace(columns[2]),
+		}
+		jobs = append(jobs, job)
+		if found < 3 {
+			job.err = fmt.Errorf("Line %d of the manifest should have 3 tab-separated columns (input, config or preset and output), found %d", line, found)
+			continue
+		}
+		if job.Input == "" || job.Config == "" || job.Output == "" {
+			job.err = fmt.Errorf("Line %d of the manifest has an empty input, config or output column", line)
+			continue
+		}
+		// Jobs writing to the same file would overwrite each other
+		if previous, ok := outputs[job.Output]; ok {
+			job.err = fmt.Errorf("Line %d of the manifest has the same output file as line %d", line, previous)
+			continue
+		}
+		outputs[job.Output] = line
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Fatalf("Failed to read the manifest: %v", err)
+	}
+	if len(jobs) == 0 {
+		logger.Fatalf("The manifest %s contains no files", file)
+	}
+	return jobs
+}
+
+// Get the path to a config file, the value can be a path or the name of a preset in the presets directory
+// A preset named delly is found as delly.yaml or delly.yml in the presets directory
+func resolveConfig(value string, presets string) (string, error) {
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		return value, nil
+	}
+	if presets == "" {
+		return "", fmt.Errorf("The config file %s doesn't exist", value)
+	}
+	for _, extension := range []string{"", ".yaml", ".yml"} {
+		path := filepath.Join(presets, value+extension)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a config file or a preset in %s", value, presets)
+}
+
+// Standardize the input file of the job, fatal errors are stored in the job
+func (job *batchJob) run(Cctx *cli.Context, config *batchConfig) {
+	start := time.Now()
+	var jobConfig *Config
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if err, ok := recovered.(fatalError); ok {
+				job.err = err
+			} else {
+				job.err = fmt.Errorf("Unexpected error: %v", recovered)
+			}
+			// Don't leave incomplete output files behind, only files created by this job are removed
+			if jobConfig != nil {
+				jobConfig.removeOutput()
+			}
+		}
+		job.duration = time.Since(start)
+	}()
+
+	if config.err != nil {
+		job.err = fmt.Errorf("Failed to open the config file: %v", config.err)
+		return
+	}
+
+	// The input, config and output of the job take precedence over the arguments of the batch command
+	flags := flag.NewFlagSet(job.Input, flag.ContinueOnError)
+	flags.String("input", job.Input, "")
+	flags.String("config", job.configPath, "")
+	flags.String("output", job.Output, "")
+	jobCtx := cli.NewContext(Cctx.App, flags, Cctx)
+
+	jobConfig = parseConfig(config.data, jobCtx)
+	if jobConfig.reference != nil {
+		defer jobConfig.reference.close()
+	}
+	Execute(jobCtx, jobConfig)
+}
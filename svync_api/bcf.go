@@ -0,0 +1,765 @@
+package svync_api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/biogo/hts/bgzf"
+)
+
+// The magic bytes at the start of BCF2 files
+var bcfMagic = []byte("BCF\x02\x02")
+
+// The types of the typed values in BCF2 files
+const (
+	bcfTypeNull  byte = 0
+	bcfTypeInt8  byte = 1
+	bcfTypeInt16 byte = 2
+	bcfTypeInt32 byte = 3
+	bcfTypeFloat byte = 5
+	bcfTypeChar  byte = 7
+)
+
+// Sentinel values used for missing values and the padding at the end of vectors
+const (
+	bcfMissingInt     int64  = math.MinInt64
+	bcfEndOfVectorInt int64  = math.MinInt64 + 1
+	bcfMissingFloat   uint32 = 0x7F800001
+	bcfEndOfVector    uint32 = 0x7F800002
+)
+
+// A struct representing a BCF2 output file
+type bcfWriter struct {
+	// The bgzipped output
+	writer *bgzf.Writer
+
+	// The header written to the output, used to get the types of the INFO and FORMAT fields
+	header *Header
+
+	// The indices of the dictionary of strings and the dictionary of contigs
+	dictionary map[string]int
+	contigs    map[string]int
+}
+
+// Create a new BCF2 writer that writes to the output file or stdout
+func newBcfWriter(file *os.File, stdout bool) *bcfWriter {
+	if stdout {
+		file = os.Stdout
+	}
+	return &bcfWriter{writer: bgzf.NewWriter(file, 1)}
+}
+
+// Flush the remaining data and close the bgzipped output
+func (bcf *bcfWriter) close() {
+	logger := newLogger()
+	if err := bcf.writer.Close(); err != nil {
+		logger.Fatalf("Failed to write the BCF file: %v", err)
+	}
+}
+
+// Write the VCF header lines as the header of the BCF2 file and build the dictionaries
+func (bcf *bcfWriter) writeHeader(lines []string) {
+	bcf.header = newHeader()
+	for _, line := range lines {
+		bcf.header.parse(line)
+	}
+
+	bcf.dictionary = map[string]int{}
+	for index, id := range bcf.header.dictionary {
+		if id != "" {
+			bcf.dictionary[id] = index
+		}
+	}
+	bcf.contigs = map[string]int{}
+	for index, id := range bcf.header.contigDictionary {
+		if id != "" {
+			bcf.contigs[id] = index
+		}
+	}
+
+	text := strings.Join(lines, "\n") + "\n\x00"
+	buffer := &bytes.Buffer{}
+	buffer.Write(bcfMagic)
+	binary.Write(buffer, binary.LittleEndian, uint32(len(text)))
+	buffer.WriteString(text)
+	bcf.write(buffer.Bytes())
+}
+
+// Write the bytes to the bgzipped output
+func (bcf *bcfWriter) write(data []byte) {
+	logger := newLogger()
+	if _, err := bcf.writer.Write(data); err != nil {
+		logger.Fatalf("Failed to write the BCF file: %v", err)
+	}
+}
+
+// Encode a standardized variant as a BCF2 record and write it
+// INFO and FORMAT values are encoded using the Type and Number of the header
+func (bcf *bcfWriter) writeVariant(v *Variant) {
+	logger := newLogger()
+
+	contig, ok := bcf.contigs[v.Chromosome]
+	if !ok {
+		logger.Fatalf("The contig %s of the variant with ID %s is not defined in the header, BCF files need a ##contig header line for every contig (use --reference to add them)", v.Chromosome, v.Id)
+	}
+
+	alleles := []string{v.Ref}
+	if v.Alt != "." && v.Alt != "" {
+		alleles = append(alleles, strings.Split(v.Alt, ",")...)
+	}
+
+	rlen := bcfRlen(v)
+
+	qual := bcfMissingFloat
+	if value, err := strconv.ParseFloat(v.Qual, 32); err == nil {
+		qual = math.Float32bits(float32(value))
+	}
+
+	infoKeys := []string{}
+	for _, key := range sortedKeys(v.Info) {
+		if !isEmpty(v.Info[key]) {
+			infoKeys = append(infoKeys, key)
+		}
+	}
+	formatKeys := []string{}
+	samples := bcf.header.Samples
+	if len(samples) > 0 {
+		formatKeys = sortedKeys(v.Format[samples[0]].Content)
+	}
+	// GT has to be the first FORMAT field
+	sort.SliceStable(formatKeys, func(i, j int) bool { return formatKeys[i] == "GT" && formatKeys[j] != "GT" })
+
+	shared := &bytes.Buffer{}
+	binary.Write(shared, binary.LittleEndian, int32(contig))
+	binary.Write(shared, binary.LittleEndian, int32(v.Pos-1))
+	binary.Write(shared, binary.LittleEndian, int32(rlen))
+	binary.Write(shared, binary.LittleEndian, qual)
+	binary.Write(shared, binary.LittleEndian, uint32(len(infoKeys))|uint32(len(alleles))<<16)
+	binary.Write(shared, binary.LittleEndian, uint32(len(samples))|uint32(len(formatKeys))<<24)
+
+	id := v.Id
+	if id == "." {
+		id = ""
+	}
+	writeTypedString(shared, id)
+	for _, allele := range alleles {
+		writeTypedString(shared, allele)
+	}
+
+	filters := []int64{}
+	if v.Filter != "." && v.Filter != "" {
+		for _, filter := range strings.Split(v.Filter, ";") {
+			filters = append(filters, int64(bcf.key(filter, v)))
+		}
+	}
+	writeTypedInts(shared, filters)
+
+	for _, key := range infoKeys {
+		writeTypedInts(shared, []int64{int64(bcf.key(key, v))})
+		bcf.writeInfo(shared, key, v)
+	}
+
+	indiv := &bytes.Buffer{}
+	for _, key := range formatKeys {
+		writeTypedInts(indiv, []int64{int64(bcf.key(key, v))})
+		bcf.writeFormat(indiv, key, v)
+	}
+
+	record := &bytes.Buffer{}
+	binary.Write(record, binary.LittleEndian, uint32(shared.Len()))
+	binary.Write(record, binary.LittleEndian, uint32(indiv.Len()))
+	record.Write(shared.Bytes())
+	record.Write(indiv.Bytes())
+	bcf.write(record.Bytes())
+}
+
+// Get the length of the region covered by the variant, used in the index of BCF files
+// Variants with an END on the same chromosome cover the region up to END, other variants only cover their REF
+// The END of breakends can be on the partner chromosome, so breakends always use their REF
+func bcfRlen(v *Variant) int64 {
+	rlen := int64(len(v.Ref))
+	if svtype := v.svtype(); svtype == "BND" || svtype == "TRA" || isBreakendAllele(v.Alt) {
+		return rlen
+	}
+	if chr2, ok := v.Info["CHR2"]; ok && len(chr2) > 0 && chr2[0] != "" && chr2[0] != v.Chromosome {
+		return rlen
+	}
+	if end, err := infoInt(v, "END"); err == nil && end >= v.Pos {
+		rlen = end - v.Pos + 1
+	}
+	return rlen
+}
+
+// Get the index of a FILTER, INFO or FORMAT ID in the dictionary of strings
+func (bcf *bcfWriter) key(id string, v *Variant) int {
+	logger := newLogger()
+	index, ok := bcf.dictionary[id]
+	if !ok {
+		logger.Fatalf("The field %s of the variant with ID %s is not defined in the header, BCF files need a header line for every FILTER, INFO and FORMAT field", id, v.Id)
+	}
+	return index
+}
+
+// Encode the value of an INFO field using its type
+func (bcf *bcfWriter) writeInfo(buffer *bytes.Buffer, key string, v *Variant) {
+	values := strings.Split(strings.Join(v.Info[key], ","), ",")
+	switch strings.ToLower(bcf.header.Info[key].Type) {
+	case "flag":
+		writeTypeDescriptor(buffer, 0, bcfTypeNull)
+	case "integer":
+		writeTypedInts(buffer, parseBcfInts(values, key, v))
+	case "float":
+		floats := parseBcfFloats(values, key, v)
+		writeTypeDescriptor(buffer, len(floats), bcfTypeFloat)
+		binary.Write(buffer, binary.LittleEndian, floats)
+	default:
+		writeTypedString(buffer, strings.Join(v.Info[key], ","))
+	}
+}
+
+// Encode the values of a FORMAT field for all samples using its type
+// The values of all samples are padded to the same length
+func (bcf *bcfWriter) writeFormat(buffer *bytes.Buffer, key string, v *Variant) {
+	samples := bcf.header.Samples
+	formatType := strings.ToLower(bcf.header.Format[key].Type)
+	if key == "GT" {
+		formatType = "genotype"
+	}
+
+	switch formatType {
+	case "integer", "genotype":
+		sampleValues := [][]int64{}
+		all := []int64{}
+		for _, sample := range samples {
+			var values []int64
+			if key == "GT" {
+				values = encodeGenotype(strings.Join(v.Format[sample].Content[key], ","))
+			} else {
+				values = parseBcfInts(formatValues(v, sample, key), key, v)
+			}
+			sampleValues = append(sampleValues, values)
+			all = append(all, values...)
+		}
+		size := maxLength(sampleValues)
+		intType := bcfIntType(all)
+		writeTypeDescriptor(buffer, size, intType)
+		for _, values := range sampleValues {
+			writeInts(buffer, padInts(values, size), intType)
+		}
+	case "float":
+		sampleValues := [][]uint32{}
+		size := 0
+		for _, sample := range samples {
+			values := parseBcfFloats(formatValues(v, sample, key), key, v)
+			sampleValues = append(sampleValues, values)
+			size = max(size, len(values))
+		}
+		writeTypeDescriptor(buffer, size, bcfTypeFloat)
+		for _, values := range sampleValues {
+			for len(values) < size {
+				values = append(values, bcfEndOfVector)
+			}
+			binary.Write(buffer, binary.LittleEndian, values)
+		}
+	default:
+		sampleValues := []string{}
+		size := 0
+		for _, sample := range samples {
+			value := strings.Join(formatValues(v, sample, key), ",")
+			sampleValues = append(sampleValues, value)
+			size = max(size, len(value))
+		}
+		writeTypeDescriptor(buffer, size, bcfTypeChar)
+		for _, value := range sampleValues {
+			buffer.WriteString(value)
+			buffer.Write(make([]byte, size-len(value)))
+		}
+	}
+}
+
+// Get the values of a FORMAT field of a sample, missing fields get a missing value
+func formatValues(v *Variant, sample string, key string) []string {
+	values, ok := v.Format[sample].Content[key]
+	if !ok || len(values) == 0 {
+		return []string{"."}
+	}
+	return strings.Split(strings.Join(values, ","), ",")
+}
+
+// Encode a genotype (e.g. 0/1 or 1|1) as integers
+// Every allele is encoded as (allele + 1) << 1 with the lowest bit set when it is phased
+func encodeGenotype(genotype string) []int64 {
+	encoded := []int64{}
+	phased := int64(0)
+	allele := ""
+	for _, letter := range genotype + "/" {
+		if letter != '/' && letter != '|' {
+			allele += string(letter)
+			continue
+		}
+		value := int64(0)
+		if index, err := strconv.ParseInt(allele, 10, 64); err == nil {
+			value = (index + 1) << 1
+		}
+		encoded = append(encoded, value|phased)
+		phased = 0
+		if letter == '|' {
+			phased = 1
+		}
+		allele = ""
+	}
+	return encoded
+}
+
+// Decode a genotype that is encoded as integers
+func decodeGenotype(values []int64) string {
+	genotype := ""
+	for index, value := range values {
+		if value == bcfEndOfVectorInt {
+			break
+		}
+		if index > 0 {
+			if value&1 == 1 {
+				genotype += "|"
+			} else {
+				genotype += "/"
+			}
+		}
+		if value == bcfMissingInt || value>>1 == 0 {
+			genotype += "."
+		} else {
+			genotype += fmt.Sprint(value>>1 - 1)
+		}
+	}
+	if genotype == "" {
+		return "."
+	}
+	return genotype
+}
+
+// Parse the values of an Integer field, missing values (.) get the missing sentinel
+func parseBcfInts(values []string, key string, v *Variant) []int64 {
+	logger := newLogger()
+	ints := []int64{}
+	for _, value := range values {
+		if value == "." || value == "" {
+			ints = append(ints, bcfMissingInt)
+			continue
+		}
+		number, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			logger.Fatalf("Failed to encode the value '%s' of the Integer field %s of the variant with ID %s: %v", value, key, v.Id, err)
+		}
+		ints = append(ints, number)
+	}
+	return ints
+}
+
+// Parse the values of a Float field, missing values (.) get the missing sentinel
+func parseBcfFloats(values []string, key string, v *Variant) []uint32 {
+	logger := newLogger()
+	floats := []uint32{}
+	for _, value := range values {
+		if value == "." || value == "" {
+			floats = append(floats, bcfMissingFloat)
+			continue
+		}
+		number, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			logger.Fatalf("Failed to encode the value '%s' of the Float field %s of the variant with ID %s: %v", value, key, v.Id, err)
+		}
+		floats = append(floats, math.Float32bits(float32(number)))
+	}
+	return floats
+}
+
+// Get the length of the longest list of values
+func maxLength(values [][]int64) int {
+	size := 0
+	for _, value := range values {
+		size = max(size, len(value))
+	}
+	return size
+}
+
+// Pad the integers to the given size with the end of vector sentinel
+func padInts(values []int64, size int) []int64 {
+	for len(values) < size {
+		values = append(values, bcfEndOfVectorInt)
+	}
+	return values
+}
+
+// Get the smallest integer type that can hold all values
+// The lowest values of every type are reserved for the sentinels
+func bcfIntType(values []int64) byte {
+	intType := bcfTypeInt8
+	for _, value := range values {
+		if value == bcfMissingInt || value == bcfEndOfVectorInt {
+			continue
+		}
+		if value < -32760 || value > 32767 {
+			return bcfTypeInt32
+		}
+		if value < -120 || value > 127 {
+			intType = bcfTypeInt16
+		}
+	}
+	return intType
+}
+
+// Write the type and the number of values of a typed value
+// Sizes of 15 and more are written as a typed integer after the type
+func writeTypeDescriptor(buffer *bytes.Buffer, size int, valueType byte) {
+	if size < 15 {
+		buffer.WriteByte(byte(size)<<4 | valueType)
+		return
+	}
+	buffer.WriteByte(15<<4 | valueType)
+	writeTypedInts(buffer, []int64{int64(size)})
+}
+
+// Write a typed vector of integers using the smallest integer type
+// Empty vectors are written as a typed null value
+func writeTypedInts(buffer *bytes.Buffer, values []int64) {
+	if len(values) == 0 {
+		writeTypeDescriptor(buffer, 0, bcfTypeNull)
+		return
+	}
+	intType := bcfIntType(values)
+	writeTypeDescriptor(buffer, len(values), intType)
+	writeInts(buffer, values, intType)
+}
+
+// Write the integers with the given integer type
+func writeInts(buffer *bytes.Buffer, values []int64, intType byte) {
+	for _, value := range values {
+		switch intType {
+		case bcfTypeInt8:
+			switch value {
+			case bcfMissingInt:
+				value = math.MinInt8
+			case bcfEndOfVectorInt:
+				value = math.MinInt8 + 1
+			}
+			buffer.WriteByte(byte(int8(value)))
+		case bcfTypeInt16:
+			switch value {
+			case bcfMissingInt:
+				value = math.MinInt16
+			case bcfEndOfVectorInt:
+				value = math.MinInt16 + 1
+			}
+			binary.Write(buffer, binary.LittleEndian, int16(value))
+		default:
+			switch value {
+			case bcfMissingInt:
+				value = math.MinInt32
+			case bcfEndOfVectorInt:
+				value = math.MinInt32 + 1
+			}
+			binary.Write(buffer, binary.LittleEndian, int32(value))
+		}
+	}
+}
+
+// Write a typed string
+func writeTypedString(buffer *bytes.Buffer, value string) {
+	writeTypeDescriptor(buffer, len(value), bcfTypeChar)
+	buffer.WriteString(value)
+}
+
+// Read the BCF2 file and call the function for every header line and every record as a VCF line
+func readBcfLines(file string, parse func(line string)) {
+	logger := newLogger()
+
+	inputFile, err := os.Open(file)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer inputFile.Close()
+
+	// BCF files are usually compressed with BGZF, but uncompressed BCF files (e.g. bcftools view -Ou) start with the magic bytes
+	var reader io.Reader = bufio.NewReader(inputFile)
+	if start, _ := reader.(*bufio.Reader).Peek(2); bytes.Equal(start, []byte{0x1f, 0x8b}) {
+		bgReader, err := bgzf.NewReader(reader, 1)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer bgReader.Close()
+		reader = bgReader
+	}
+
+	magic := make([]byte, len(bcfMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic[:4], bcfMagic[:4]) {
+		logger.Fatalf("The file %s is not a BCF2 file", file)
+	}
+
+	var textLength uint32
+	if err := binary.Read(reader, binary.LittleEndian, &textLength); err != nil {
+		logger.Fatalf("Failed to read the header of %s: %v", file, err)
+	}
+	text := make([]byte, textLength)
+	if _, err := io.ReadFull(reader, text); err != nil {
+		logger.Fatalf("Failed to read the header of %s: %v", file, err)
+	}
+
+	header := newHeader()
+	for _, line := range strings.Split(strings.TrimRight(string(text), "\x00\n"), "\n") {
+		header.parse(line)
+		parse(line)
+	}
+
+	for {
+		var lengths [2]uint32
+		if err := binary.Read(reader, binary.LittleEndian, &lengths); err != nil {
+			if err == io.EOF {
+				break
+			}
+			logger.Fatalf("Failed to read a record of %s: %v", file, err)
+		}
+		record := make([]byte, lengths[0]+lengths[1])
+		if _, err := io.ReadFull(reader, record); err != nil {
+			logger.Fatalf("Failed to read a record of %s: %v", file, err)
+		}
+		parse(header.decodeBcfRecord(record))
+	}
+}
+
+// Decode a BCF2 record to a VCF line using the dictionaries of the header
+func (header *Header) decodeBcfRecord(record []byte) string {
+	reader := bytes.NewReader(record)
+	var fixed struct {
+		Chrom     int32
+		Pos       int32
+		Rlen      int32
+		Qual      uint32
+		InfoAllel uint32
+		FmtSample uint32
+	}
+	binary.Read(reader, binary.LittleEndian, &fixed)
+
+	nInfo := int(fixed.InfoAllel & 0xFFFF)
+	nAllele := int(fixed.InfoAllel >> 16)
+	nSample := int(fixed.FmtSample & 0xFFFFFF)
+	nFormat := int(fixed.FmtSample >> 24)
+
+	qual := "."
+	if fixed.Qual != bcfMissingFloat {
+		qual = formatBcfFloat(fixed.Qual)
+	}
+
+	id := orMissing(readTypedString(reader))
+	alleles := []string{}
+	for i := 0; i < nAllele; i++ {
+		alleles = append(alleles, readTypedString(reader))
+	}
+	ref, alt := ".", "."
+	if len(alleles) > 0 {
+		ref = alleles[0]
+	}
+	if len(alleles) > 1 {
+		alt = strings.Join(alleles[1:], ",")
+	}
+
+	filters := []string{}
+	size, valueType := readTypeDescriptor(reader)
+	for _, index := range readInts(reader, size, valueType) {
+		filters = append(filters, header.dictionaryId(index))
+	}
+
+	info := []string{}
+	for i := 0; i < nInfo; i++ {
+		key := header.dictionaryId(readTypedInts(reader)[0])
+		size, valueType := readTypeDescriptor(reader)
+		if size == 0 || valueType == bcfTypeNull {
+			info = append(info, key)
+			continue
+		}
+		info = append(info, fmt.Sprintf("%s=%s", key, strings.Join(readValues(reader, size, valueType), ",")))
+	}
+
+	formatKeys := []string{}
+	samples := make([][]string, nSample)
+	for i := 0; i < nFormat; i++ {
+		key := header.dictionaryId(readTypedInts(reader)[0])
+		formatKeys = append(formatKeys, key)
+		size, valueType := readTypeDescriptor(reader)
+		for sample := 0; sample < nSample; sample++ {
+			value := "."
+			if key == "GT" && valueType != bcfTypeChar {
+				value = decodeGenotype(readInts(reader, size, valueType))
+			} else if values := readValues(reader, size, valueType); len(values) > 0 {
+				value = orMissing(strings.Join(values, ","))
+			}
+			samples[sample] = append(samples[sample], value)
+		}
+	}
+
+	columns := []string{
+		header.contigId(fixed.Chrom),
+		fmt.Sprint(fixed.Pos + 1),
+		id,
+		ref,
+		alt,
+		qual,
+		orMissing(strings.Join(filters, ";")),
+		orMissing(strings.Join(info, ";")),
+	}
+	if nSample > 0 {
+		columns = append(columns, strings.Join(formatKeys, ":"))
+		for _, sample := range samples {
+			columns = append(columns, strings.Join(sample, ":"))
+		}
+	}
+	return strings.Join(columns, "\t")
+}
+
+// Get the ID of an index of the dictionary of strings
+func (header *Header) dictionaryId(index int64) string {
+	logger := newLogger()
+	if index < 0 || index >= int64(len(header.dictionary)) {
+		logger.Fatalf("The index %d is not defined in the dictionary of the BCF header", index)
+	}
+	return header.dictionary[index]
+}
+
+// Get the ID of an index of the dictionary of contigs
+func (header *Header) contigId(index int32) string {
+	logger := newLogger()
+	if index < 0 || int(index) >= len(header.contigDictionary) || header.contigDictionary[index] == "" {
+		logger.Fatalf("The contig index %d is not defined in the BCF header", index)
+	}
+	return header.contigDictionary[index]
+}
+
+// Read the type and the number of values of a typed value
+func readTypeDescriptor(reader *bytes.Reader) (int, byte) {
+	descriptor, _ := reader.ReadByte()
+	size := int(descriptor >> 4)
+	if size == 15 {
+		size = int(readTypedInts(reader)[0])
+	}
+	return size, descriptor & 0x0F
+}
+
+// Read a typed vector of integers
+func readTypedInts(reader *bytes.Reader) []int64 {
+	size, valueType := readTypeDescriptor(reader)
+	return readInts(reader, size, valueType)
+}
+
+// Read a typed string
+func readTypedString(reader *bytes.Reader) string {
+	size, valueType := readTypeDescriptor(reader)
+	return strings.Join(readValues(reader, size, valueType), ",")
+}
+
+// Read the integers with the given integer type, the sentinels are converted to their 64-bit values
+func readInts(reader *bytes.Reader, size int, intType byte) []int64 {
+	values := []int64{}
+	for i := 0; i < size; i++ {
+		var value int64
+		switch intType {
+		case bcfTypeInt8:
+			var v int8
+			binary.Read(reader, binary.LittleEndian, &v)
+			value = int64(v)
+			if v == math.MinInt8 {
+				value = bcfMissingInt
+			} else if v == math.MinInt8+1 {
+				value = bcfEndOfVectorInt
+			}
+		case bcfTypeInt16:
+			var v int16
+			binary.Read(reader, binary.LittleEndian, &v)
+			value = int64(v)
+			if v == math.MinInt16 {
+				value = bcfMissingInt
+			} else if v == math.MinInt16+1 {
+				value = bcfEndOfVectorInt
+			}
+		default:
+			var v int32
+			binary.Read(reader, binary.LittleEndian, &v)
+			value = int64(v)
+			if v == math.MinInt32 {
+				value = bcfMissingInt
+			} else if v == math.MinInt32+1 {
+				value = bcfEndOfVectorInt
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// Read the values of a typed value as VCF strings
+// Missing values become "." and the padding at the end of vectors is removed
+// Strings are returned as a single value
+func readValues(reader *bytes.Reader, size int, valueType byte) []string {
+	values := []string{}
+	switch valueType {
+	case bcfTypeNull:
+		return values
+	case bcfTypeChar:
+		data := make([]byte, size)
+		io.ReadFull(reader, data)
+		return []string{strings.TrimRight(string(data), "\x00")}
+	case bcfTypeFloat:
+		for i := 0; i < size; i++ {
+			var value uint32
+			binary.Read(reader, binary.LittleEndian, &value)
+			if value == bcfEndOfVector {
+				continue
+			} else if value == bcfMissingFloat {
+				values = append(values, ".")
+			} else {
+				values = append(values, formatBcfFloat(value))
+			}
+		}
+	default:
+		for _, value := range readInts(reader, size, valueType) {
+			if value == bcfEndOfVectorInt {
+				continue
+			} else if value == bcfMissingInt {
+				values = append(values, ".")
+			} else {
+				values = append(values, fmt.Sprint(value))
+			}
+		}
+	}
+	return values
+}
+
+// Format the bits of a 32-bit float as the shortest decimal representation
+func formatBcfFloat(bits uint32) string {
+	return strconv.FormatFloat(float64(math.Float32frombits(bits)), 'g', -1, 32)
+}
+
+// Add an ID of a FILTER, INFO, FORMAT or contig header line to a dictionary of a BCF file
+// The index can be set with the IDX attribute, otherwise the ID is added to the end
+func addToDictionary(dictionary []string, id string, idx string) []string {
+	for _, existing := range dictionary {
+		if existing == id {
+			return dictionary
+		}
+	}
+	index, err := strconv.Atoi(idx)
+	if err != nil || index < 0 {
+		return append(dictionary, id)
+	}
+	for len(dictionary) <= index {
+		dictionary = append(dictionary, "")
+	}
+	dictionary[index] = id
+	return dictionary
+}
@@ -0,0 +1,167 @@
+package svync_api
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/biogo/hts/bgzf"
+)
+
+// The header of the VCF records used to test the BCF encoding
+var bcfTestHeader = []string{
+	`##fileformat=VCFv4.2`,
+	`##FILTER=<ID=PASS,Description="All filters passed">`,
+	`##FILTER=<ID=LowQual,Description="Low quality">`,
+	`##FILTER=<ID=q10,Description="Quality below 10">`,
+	`##INFO=<ID=AF,Number=A,Type=Float,Description="Allele frequency">`,
+	`##INFO=<ID=CIPOS,Number=2,Type=Integer,Description="Confidence interval around POS">`,
+	`##INFO=<ID=END,Number=1,Type=Integer,Description="End position">`,
+	`##INFO=<ID=IMPRECISE,Number=0,Type=Flag,Description="Imprecise structural variation">`,
+	`##INFO=<ID=LIST,Number=.,Type=Integer,Description="A list of integers">`,
+	`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+	`##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">`,
+	`##FORMAT=<ID=AD,Number=.,Type=Integer,Description="Allelic depths">`,
+	`##FORMAT=<ID=FT,Number=1,Type=String,Description="Sample filter">`,
+	`##FORMAT=<ID=GQ,Number=1,Type=Float,Description="Genotype quality">`,
+	`##contig=<ID=chr1,length=1000000>`,
+	`##contig=<ID=chr2,length=1000000>`,
+	"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tS1\tS2\tS3",
+}
+
+// Write the VCF records to a BCF file and return the path of the file
+func writeTestBcf(t *testing.T, headerLines []string, records []string) string {
+	t.Helper()
+	Cctx := testContext()
+
+	path := filepath.Join(t.TempDir(), "test.bcf")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	header := newHeader()
+	for _, line := range headerLines {
+		header.parse(line)
+	}
+	bcf := newBcfWriter(file, false)
+	bcf.writeHeader(headerLines)
+	for _, record := range records {
+		bcf.writeVariant(createVariant(record, header, Cctx))
+	}
+	bcf.close()
+	return path
+}
+
+// Read the records of a BCF file as VCF lines
+func readTestBcf(path string) []string {
+	records := []string{}
+	readBcfLines(path, func(line string) {
+		if !strings.HasPrefix(line, "#") {
+			records = append(records, line)
+		}
+	})
+	return records
+}
+
+func TestBcfRoundTrip(t *testing.T) {
+	// The INFO fields are sorted and GT is the first FORMAT field, like the BCF writer writes them
+	records := []string{
+		// Integers of different widths, a multi-sample FORMAT with vectors of different lengths and missing values
+		"chr1\t100\tdel1\tN\t<DEL>\t50\tPASS\tCIPOS=-5,5;END=200;SVTYPE=DEL\tGT:AD:FT:GQ\t0/1:1,2:PASS:10.5\t1|1:3:.:.\t./.:.:LowQual:99",
+		// A flag, multiple filters, a missing QUAL and 32-bit integers with a missing value
+		"chr2\t5000\tbnd1\tN\tN[chr1:100[\t.\tLowQual;q10\tIMPRECISE;SVTYPE=BND\tGT:AD:FT:GQ\t0/1:100000,5:PASS:1\t0/0:0,0:PASS:2.25\t1/1:.,7:PASS:0.5",
+		// Vectors and strings with 15 or more values and a float INFO field
+		"chr1\t300\tan_identifier_longer_than_15\tN\t<INS>\t3.5\tPASS\tAF=0.25;LIST=1,-2,3,4,5,6,7,8,9,10,11,12,13,14,15,-32000;SVTYPE=INS\tGT:AD:FT:GQ\t0/1:1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16:PASS:1\t0/1:1:PASS:1\t0/1:1:PASS:1",
+		// Missing values in INFO vectors
+		"chr1\t400\tdup1\tN\t<DUP>\t10\tPASS\tCIPOS=.,10;END=70000;SVTYPE=DUP\tGT:AD:FT:GQ\t0/1:.:.:.\t.:.:.:.\t0:1:PASS:1",
+	}
+	path := writeTestBcf(t, bcfTestHeader, records)
+
+	got := readTestBcf(path)
+	if len(got) != len(records) {
+		t.Fatalf("read %d records, want %d", len(got), len(records))
+	}
+	for index, record := range records {
+		if got[index] != record {
+			t.Errorf("record %d:\n got: %q\nwant: %q", index, got[index], record)
+		}
+	}
+}
+
+func TestBcfReadUncompressed(t *testing.T) {
+	records := []string{
+		"chr1\t100\tdel1\tN\t<DEL>\t50\tPASS\tEND=200;SVTYPE=DEL\tGT:AD:FT:GQ\t0/1:1,2:PASS:10.5\t1|1:3:.:.\t./.:.:LowQual:99",
+	}
+	path := writeTestBcf(t, bcfTestHeader, records)
+
+	// Decompress the BGZF file like bcftools view -Ou
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := bgzf.NewReader(file, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncompressed := filepath.Join(t.TempDir(), "uncompressed.bcf")
+	if err := os.WriteFile(uncompressed, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readTestBcf(uncompressed); !reflect.DeepEqual(got, records) {
+		t.Errorf("got %q, want %q", got, records)
+	}
+}
+
+func TestBcfDictionaryIdx(t *testing.T) {
+	header := newHeader()
+	for _, line := range []string{
+		`##FILTER=<ID=PASS,Description="All filters passed",IDX=0>`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant",IDX=3>`,
+		`##FILTER=<ID=LowQual,Description="Low quality",IDX=1>`,
+		`##contig=<ID=chr2,length=1000,IDX=1>`,
+		`##contig=<ID=chr1,length=1000,IDX=0>`,
+	} {
+		header.parse(line)
+	}
+
+	if got, want := header.dictionary, []string{"PASS", "LowQual", "", "SVTYPE"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dictionary = %q, want %q", got, want)
+	}
+	if got := header.contigId(0); got != "chr1" {
+		t.Errorf("contigId(0) = %s, want chr1", got)
+	}
+	if got := header.contigId(1); got != "chr2" {
+		t.Errorf("contigId(1) = %s, want chr2", got)
+	}
+}
+
+func TestBcfRlen(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant *Variant
+		want    int64
+	}{
+		{"deletion with END", &Variant{Chromosome: "chr1", Pos: 100, Ref: "N", Alt: "<DEL>", Info: map[string][]string{"SVTYPE": {"DEL"}, "END": {"200"}}}, 101},
+		{"literal deletion", &Variant{Chromosome: "chr1", Pos: 100, Ref: "ACGT", Alt: "A", Info: map[string][]string{}}, 4},
+		{"breakend with END on the partner", &Variant{Chromosome: "chr1", Pos: 100, Ref: "N", Alt: "N[chr2:50[", Info: map[string][]string{"SVTYPE": {"BND"}, "END": {"50"}}}, 1},
+		{"translocation with CHR2", &Variant{Chromosome: "chr1", Pos: 100, Ref: "N", Alt: "<TRA>", Info: map[string][]string{"SVTYPE": {"TRA"}, "CHR2": {"chr2"}, "END": {"5000"}}}, 1},
+		{"inversion with END on another chromosome", &Variant{Chromosome: "chr1", Pos: 100, Ref: "N", Alt: "<INV>", Info: map[string][]string{"SVTYPE": {"INV"}, "CHR2": {"chr2"}, "END": {"5000"}}}, 1},
+		{"inversion with CHR2 on the same chromosome", &Variant{Chromosome: "chr1", Pos: 100, Ref: "N", Alt: "<INV>", Info: map[string][]string{"SVTYPE": {"INV"}, "CHR2": {"chr1"}, "END": {"199"}}}, 100},
+	}
+	for _, test := range tests {
+		if got := bcfRlen(test.variant); got != test.want {
+			t.Errorf("%s: bcfRlen() = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package svync_api
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// Write the column headers of the BEDPE file
+func writeBedpeHeader(Cctx *cli.Context, header *Header, file *os.File, stdout bool) {
+	columnHeaders := []string{"#chrom1", "start1", "end1", "chrom2", "start2", "end2", "name", "score", "strand1", "strand2", "svtype"}
+	columnHeaders = append(columnHeaders, extraColumnHeaders(Cctx.StringSlice("columns"), header.Samples)...)
+	writeLine(strings.Join(columnHeaders, "\t"), file, stdout)
+}
+
+// Convert a standardized variant to a BEDPE line with 0-based coordinates
+// The intervals of the breakpoints are widened with CIPOS and CIEND
+func (v *Variant) bedpe(columns []string) string {
+	chrom2, pos2, strand1, strand2 := v.secondBreakpoint()
+
+	ciposStart, ciposEnd := infoInterval(v, "CIPOS")
+	start1 := max(0, v.Pos-1+ciposStart)
+	end1 := v.Pos + ciposEnd
+
+	start2, end2 := "-1", "-1"
+	if chrom2 != "." {
+		ciendStart, ciendEnd := infoInterval(v, "CIEND")
+		start2 = fmt.Sprint(max(0, pos2-1+ciendStart))
+		end2 = fmt.Sprint(pos2 + ciendEnd)
+	}
+
+	svtype := "."
+	if keys := v.altKeys(); len(keys) > 0 {
+		svtype = keys[len(keys)-1]
+	}
+
+	fields := []string{
+		v.Chromosome,
+		fmt.Sprint(start1),
+		fmt.Sprint(end1),
+		chrom2,
+		start2,
+		end2,
+		v.Id,
+		v.Qual,
+		strand1,
+		strand2,
+		svtype,
+	}
+	fields = append(fields, v.extraColumns(columns, ".")...)
+	return strings.Join(fields, "\t")
+}
+
+// Get the chromosome, position and strands of the second breakpoint of a variant
+// The partner of breakends is parsed from the ALT, other variants use CHR2 and END
+// The chromosome is "." when the second breakpoint is unknown (e.g. single breakends)
+func (v *Variant) secondBreakpoint() (string, int64, string, string) {
+	if chrom, pos, strand1, strand2, ok := v.breakendPartner(); ok {
+		return chrom, pos, strand1, strand2
+	}
+	if isBreakendAllele(v.Alt) {
+		return ".", -1, ".", "."
+	}
+
+	chrom2 := v.Chromosome
+	if chr2, ok := v.Info["CHR2"]; ok && len(chr2) > 0 && chr2[0] != "" {
+		chrom2 = chr2[0]
+	}
+	pos2, err := infoInt(v, "END")
+	if err != nil {
+		pos2 = v.Pos
+	}
+
+	strand1, strand2 := ".", "."
+	if strands, ok := v.Info["STRANDS"]; ok && len(strands) > 0 && len(strands[0]) >= 2 {
+		strand1, strand2 = strands[0][:1], strands[0][1:2]
+	} else if keys := v.altKeys(); len(keys) > 0 {
+		switch keys[len(keys)-1] {
+		case "DEL", "INS":
+			strand1, strand2 = "+", "-"
+		case "DUP":
+			strand1, strand2 = "-", "+"
+		}
+	}
+	return chrom2, pos2, strand1, strand2
+}
+
+// Get the values of the extra INFO and FORMAT columns (e.g. INFO/SVLEN or FORMAT/GT)
+// FORMAT columns get a value for each sample
+func (v *Variant) extraColumns(columns []string, missing string) []string {
+	values := []string{}
+	for _, column := range columns {
+		split := strings.SplitN(column, "/", 2)
+		switch split[0] {
+		case "INFO":
+			value, ok := v.Info[split[1]]
+			if !ok {
+				values = append(values, missing)
+			} else if len(value) == 0 {
+				values = append(values, "true")
+			} else {
+				values = append(values, strings.Join(value, ","))
+			}
+		case "FORMAT":
+			for _, sample := range v.Header.Samples {
+				value, ok := v.Format[sample].Content[split[1]]
+				if !ok || len(value) == 0 {
+					values = append(values, missing)
+				} else {
+					values = append(values, strings.Join(value, ","))
+				}
+			}
+		}
+	}
+	return values
+}
+
+// Get the column headers of the extra INFO and FORMAT columns
+// FORMAT columns get a column for each sample (<sample>_<field>)
+func extraColumnHeaders(columns []string, samples []string) []string {
+	headers := []string{}
+	for _, column := range columns {
+		split := strings.SplitN(column, "/", 2)
+		switch split[0] {
+		case "INFO":
+			headers = append(headers, split[1])
+		case "FORMAT":
+			for _, sample := range samples {
+				headers = append(headers, fmt.Sprintf("%s_%s", sample, split[1]))
+			}
+		}
+	}
+	return headers
+}
+
+// The default columns (1-based) of BEDPE files
+var defaultBedpeColumns = map[string]int{
+	"chrom1":  1,
+	"start1":  2,
+	"end1":    3,
+	"chrom2":  4,
+	"start2":  5,
+	"end2":    6,
+	"name":    7,
+	"score":   8,
+	"strand1": 9,
+	"strand2": 10,
+}
+
+// The default columns (1-based) of BED files
+var defaultBedColumns = map[string]int{
+	"chrom":  1,
+	"start":  2,
+	"end":    3,
+	"name":   4,
+	"score":  5,
+	"strand": 6,
+}
+
+// Get the format of the input file from the config or from the extension of the input file
+func (input *ConfigInputFile) inputFormat(file string) string {
+	logger := newLogger()
+
+	format := strings.ToLower(input.Format)
+	if format == "" {
+		file = strings.TrimSuffix(file, ".gz")
+		format = "vcf"
+		if strings.HasSuffix(file, ".bcf") {
+			format = "bcf"
+		} else if strings.HasSuffix(file, ".bedpe") {
+			format = "bedpe"
+		} else if strings.HasSuffix(file, ".bed") {
+			format = "bed"
+		}
+	}
+
+	switch format {
+	case "vcf", "bcf", "bedpe", "bed":
+		return format
+	}
+	logger.Fatalf("The input format '%s' is not supported, use 'vcf', 'bcf', 'bedpe' or 'bed'", format)
+	return ""
+}
+
+// Check if the input format is BEDPE or BED
+func isBedFormat(format string) bool {
+	return format == "bedpe" || format == "bed"
+}
+
+// Check if a line of a BED or BEDPE file is a comment or header line
+func isBedComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "track") || strings.HasPrefix(line, "browser")
+}
+
+// Create the header of a BED or BEDPE file with the INFO fields that are created from the columns
+func (input *ConfigInputFile) createHeader(format string) *Header {
+	header := newHeader()
+	infoFields := []HeaderLineIdNumberTypeDescription{
+		{Id: "SVTYPE", Number: "1", Type: "String", Description: "Type of structural variant"},
+		{Id: "END", Number: "1", Type: "Integer", Description: "End position of the variant described in this record"},
+		{Id: "SVLEN", Number: "1", Type: "Integer", Description: "Difference in length between REF and ALT alleles"},
+		{Id: "CHR2", Number: "1", Type: "String", Description: "Chromosome for the end position of the variant described in this record"},
+		{Id: "CIPOS", Number: "2", Type: "Integer", Description: "Confidence interval around POS"},
+		{Id: "CIEND", Number: "2", Type: "Integer", Description: "Confidence interval around END"},
+		{Id: "STRANDS", Number: "1", Type: "String", Description: "Strand orientation of the breakpoints"},
+		{Id: "IMPRECISE", Number: "0", Type: "Flag", Description: "Imprecise structural variation"},
+	}
+	for _, field := range sortedKeys(input.Info) {
+		infoFields = append(infoFields, HeaderLineIdNumberTypeDescription{Id: field, Number: "1", Type: "String", Description: fmt.Sprintf("Column %d of the %s file", input.Info[field], strings.ToUpper(format))})
+	}
+	for _, field := range infoFields {
+		header.Info[field.Id] = field
+	}
+	return header
+}
+
+// Create a variant from a line of a BED or BEDPE file using the column mapping of the config
+func (input *ConfigInputFile) createVariant(line string, header *Header, format string) *Variant {
+	logger := newLogger()
+
+	data := strings.Split(line, "\t")
+	defaults := defaultBedpeColumns
+	if format == "bed" {
+		defaults = defaultBedColumns
+	}
+
+	// Get the value of a column, returns an empty string when the column doesn't exist
+	column := func(name string) string {
+		index, ok := input.Columns[name]
+		if !ok {
+			index = defaults[name]
+		}
+		if index < 1 || index > len(data) || data[index-1] == "." {
+			return ""
+		}
+		return data[index-1]
+	}
+	position := func(name string) int64 {
+		value := column(name)
+		if value == "" {
+			logger.Fatalf("The %s column of line '%s' is missing", name, line)
+		}
+		position, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			logger.Fatalf("Failed to parse the %s column of line '%s': %v", name, line, err)
+		}
+		return position
+	}
+	// Get the value of a position column that can be unknown, BEDPE files use . or -1 for unknown positions
+	optionalPosition := func(name string) (int64, bool) {
+		if column(name) == "" {
+			return 0, false
+		}
+		value := position(name)
+		return value, value >= 0
+	}
+
+	variant := newVariant()
+	variant.Header = header
+	variant.Ref = "N"
+	variant.Id = orMissing(column("name"))
+	variant.Qual = orMissing(column("score"))
+	variant.Filter = orMissing(column("filter"))
+
+	svtype := column("svtype")
+	if svtype == "" {
+		svtype = input.Svtype
+	}
+
+	if format == "bed" {
+		if svtype == "" {
+			logger.Fatalf("BED files need a svtype column or a default svtype in the input section of the config")
+		}
+		start := position("start")
+		end := position("end")
+		variant.Chromosome = column("chrom")
+		variant.Pos = max(1, start)
+		variant.Info["END"] = []string{fmt.Sprint(end)}
+		variant.Info["SVLEN"] = []string{fmt.Sprint(svLength(svtype, end-start))}
+	} else {
+		variant.Chromosome = column("chrom1")
+		chrom2 := column("chrom2")
+		start1, end1 := position("start1"), position("end1")
+		start2, hasStart2 := optionalPosition("start2")
+		end2, hasEnd2 := optionalPosition("end2")
+
+		// The position is the middle of the breakpoint interval, the rest of the interval is added to the confidence interval
+		variant.Pos = max(1, (start1+1+end1)/2)
+		variant.Info["CIPOS"] = []string{fmt.Sprint(start1 + 1 - variant.Pos), fmt.Sprint(end1 - variant.Pos)}
+		if end1-start1 > 1 {
+			variant.Info["IMPRECISE"] = []string{}
+		}
+
+		strand1 := column("strand1")
+		strand2 := column("strand2")
+		if strand1 != "" && strand2 != "" {
+			variant.Info["STRANDS"] = []string{strand1 + strand2}
+		}
+
+		// Variants with an unknown partner are single breakends, unless they have another type (e.g. INS)
+		if chrom2 == "" || !hasStart2 || !hasEnd2 {
+			if svtype == "" || svtype == "BND" || svtype == "TRA" {
+				svtype = "BND"
+				variant.Alt = singleBreakendAllele(variant.Ref, strand1)
+			}
+			variant.Info["SVTYPE"] = []string{svtype}
+			if variant.Alt == "" {
+				variant.Alt = fmt.Sprintf("<%s>", svtype)
+			}
+			input.addInfoColumns(variant, data)
+			return variant
+		}
+
+		pos2 := max(1, (start2+1+end2)/2)
+		variant.Info["CIEND"] = []string{fmt.Sprint(start2 + 1 - pos2), fmt.Sprint(end2 - pos2)}
+		if end2-start2 > 1 {
+			variant.Info["IMPRECISE"] = []string{}
+		}
+
+		if svtype == "" {
+			svtype = bedpeSvtype(variant.Chromosome, chrom2, strand1, strand2)
+		}
+
+		if svtype == "BND" || svtype == "TRA" || chrom2 != variant.Chromosome {
+			variant.Alt = breakendAllele(variant.Ref, chrom2, pos2, strand1, strand2)
+			variant.Info["CHR2"] = []string{chrom2}
+			variant.Info["END"] = []string{fmt.Sprint(pos2)}
+		} else {
+			variant.Info["END"] = []string{fmt.Sprint(pos2)}
+			variant.Info["SVLEN"] = []string{fmt.Sprint(svLength(svtype, pos2-variant.Pos))}
+		}
+	}
+
+	variant.Info["SVTYPE"] = []string{svtype}
+	if variant.Alt == "" {
+		variant.Alt = fmt.Sprintf("<%s>", svtype)
+	}
+
+	input.addInfoColumns(variant, data)
+	return variant
+}
+
+// Add the extra columns of a BED or BEDPE line as INFO fields
+func (input *ConfigInputFile) addInfoColumns(variant *Variant, data []string) {
+	for _, field := range sortedKeys(input.Info) {
+		index := input.Info[field]
+		if index >= 1 && index <= len(data) && data[index-1] != "." {
+			variant.Info[field] = []string{data[index-1]}
+		}
+	}
+}
+
+// Get the type of a BEDPE variant from its chromosomes and strands
+func bedpeSvtype(chrom1 string, chrom2 string, strand1 string, strand2 string) string {
+	if chrom1 != chrom2 {
+		return "BND"
+	}
+	switch strand1 + strand2 {
+	case "+-":
+		return "DEL"
+	case "-+":
+		return "DUP"
+	case "++", "--":
+		return "INV"
+	}
+	return "BND"
+}
+
+// Create the ALT of a breakend from the partner position and the strands
+func breakendAllele(ref string, chrom2 string, pos2 int64, strand1 string, strand2 string) string {
+	partner := fmt.Sprintf("%s:%d", chrom2, pos2)
+	switch strand1 + strand2 {
+	case "++":
+		return fmt.Sprintf("%s]%s]", ref, partner)
+	case "-+":
+		return fmt.Sprintf("]%s]%s", partner, ref)
+	case "--":
+		return fmt.Sprintf("[%s[%s", partner, ref)
+	}
+	return fmt.Sprintf("%s[%s[", ref, partner)
+}
+
+// Create the ALT of a single breakend, the sequence continues after the REF on the + strand and before the REF on the - strand
+func singleBreakendAllele(ref string, strand string) string {
+	if strand == "-" {
+		return "." + ref
+	}
+	return ref + "."
+}
+
+// Get the SVLEN of a variant, deletions get a negative length
+func svLength(svtype string, length int64) int64 {
+	if strings.HasPrefix(svtype, "DEL") {
+		return -length
+	}
+	return length
+}
+
+// Return the missing value (.) for empty values
+func orMissing(value string) string {
+	if value == "" {
+		return "."
+	}
+	return value
+}
@@ -0,0 +1,159 @@
+package svync_api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBreakendPartnerStrands(t *testing.T) {
+	tests := []struct {
+		alt     string
+		strand1 string
+		strand2 string
+	}{
+		// t[p[ joins after t to the sequence starting at p
+		{"N[chr2:100[", "+", "-"},
+		// t]p] joins after t to the reverse complement of the sequence ending at p
+		{"N]chr2:100]", "+", "+"},
+		// ]p]t joins before t to the sequence ending at p
+		{"]chr2:100]N", "-", "+"},
+		// [p[t joins before t to the reverse complement of the sequence starting at p
+		{"[chr2:100[N", "-", "-"},
+	}
+	for _, test := range tests {
+		variant := &Variant{Chromosome: "chr1", Pos: 50, Ref: "N", Alt: test.alt, Info: map[string][]string{"SVTYPE": {"BND"}}}
+		chrom, pos, strand1, strand2, ok := variant.breakendPartner()
+		if !ok || chrom != "chr2" || pos != 100 || strand1 != test.strand1 || strand2 != test.strand2 {
+			t.Errorf("breakendPartner(%s) = %s %d %s%s %v, want chr2 100 %s%s", test.alt, chrom, pos, strand1, strand2, ok, test.strand1, test.strand2)
+		}
+	}
+}
+
+func TestVariantBedpe(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant *Variant
+		want    string
+	}{
+		{
+			"deletion with confidence intervals",
+			&Variant{Chromosome: "chr1", Pos: 100, Id: "del1", Qual: "50", Alt: "<DEL>", Info: map[string][]string{"SVTYPE": {"DEL"}, "END": {"200"}, "CIPOS": {"-10", "10"}, "CIEND": {"-5,5"}}},
+			"chr1\t89\t110\tchr1\t194\t205\tdel1\t50\t+\t-\tDEL",
+		},
+		{
+			"duplication",
+			&Variant{Chromosome: "chr1", Pos: 100, Id: "dup1", Qual: ".", Alt: "<DUP:TANDEM>", Info: map[string][]string{"SVTYPE": {"DUP"}, "END": {"200"}}},
+			"chr1\t99\t100\tchr1\t199\t200\tdup1\t.\t-\t+\tDUP",
+		},
+		{
+			"inversion with strands",
+			&Variant{Chromosome: "chr1", Pos: 100, Id: "inv1", Qual: ".", Alt: "<INV>", Info: map[string][]string{"SVTYPE": {"INV"}, "END": {"200"}, "STRANDS": {"--"}}},
+			"chr1\t99\t100\tchr1\t199\t200\tinv1\t.\t-\t-\tINV",
+		},
+		{
+			"translocation with CHR2",
+			&Variant{Chromosome: "chr1", Pos: 100, Id: "tra1", Qual: ".", Alt: "<TRA>", Info: map[string][]string{"SVTYPE": {"TRA"}, "CHR2": {"chr2"}, "END": {"500"}}},
+			"chr1\t99\t100\tchr2\t499\t500\ttra1\t.\t.\t.\tTRA",
+		},
+		{
+			"breakend",
+			&Variant{Chromosome: "chr1", Pos: 100, Id: "bnd1", Qual: ".", Ref: "N", Alt: "]chr2:500]N", Info: map[string][]string{"SVTYPE": {"BND"}}},
+			"chr1\t99\t100\tchr2\t499\t500\tbnd1\t.\t-\t+\tBND",
+		},
+		{
+			"single breakend",
+			&Variant{Chromosome: "chr1", Pos: 100, Id: "sbnd1", Qual: ".", Ref: "N", Alt: "N.", Info: map[string][]string{"SVTYPE": {"BND"}}},
+			"chr1\t99\t100\t.\t-1\t-1\tsbnd1\t.\t.\t.\tBND",
+		},
+	}
+	for _, test := range tests {
+		if got := test.variant.bedpe(nil); got != test.want {
+			t.Errorf("%s:\n got: %q\nwant: %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBedpeCreateVariant(t *testing.T) {
+	input := &ConfigInputFile{}
+	header := input.createHeader("bedpe")
+
+	tests := []struct {
+		name string
+		line string
+		pos  int64
+		alt  string
+		info map[string][]string
+	}{
+		{
+			"imprecise deletion", "chr1\t89\t110\tchr1\t194\t205\tdel1\t50\t+\t-",
+			100, "<DEL>",
+			map[string][]string{"SVTYPE": {"DEL"}, "END": {"200"}, "SVLEN": {"-100"}, "CIPOS": {"-10", "10"}, "CIEND": {"-5", "5"}, "IMPRECISE": {}, "STRANDS": {"+-"}},
+		},
+		{
+			"duplication", "chr1\t99\t100\tchr1\t199\t200\tdup1\t.\t-\t+",
+			100, "<DUP>",
+			map[string][]string{"SVTYPE": {"DUP"}, "END": {"200"}, "SVLEN": {"100"}, "CIPOS": {"0", "0"}, "CIEND": {"0", "0"}, "STRANDS": {"-+"}},
+		},
+		{
+			"inversion", "chr1\t99\t100\tchr1\t199\t200\tinv1\t.\t+\t+",
+			100, "<INV>",
+			map[string][]string{"SVTYPE": {"INV"}, "END": {"200"}, "SVLEN": {"100"}, "CIPOS": {"0", "0"}, "CIEND": {"0", "0"}, "STRANDS": {"++"}},
+		},
+		{
+			"translocation joined after both breakpoints", "chr1\t99\t100\tchr2\t499\t500\tbnd1\t.\t+\t+",
+			100, "N]chr2:500]",
+			map[string][]string{"SVTYPE": {"BND"}, "CHR2": {"chr2"}, "END": {"500"}, "CIPOS": {"0", "0"}, "CIEND": {"0", "0"}, "STRANDS": {"++"}},
+		},
+		{
+			"translocation joined before the first breakpoint", "chr1\t99\t100\tchr2\t499\t500\tbnd2\t.\t-\t+",
+			100, "]chr2:500]N",
+			map[string][]string{"SVTYPE": {"BND"}, "CHR2": {"chr2"}, "END": {"500"}, "CIPOS": {"0", "0"}, "CIEND": {"0", "0"}, "STRANDS": {"-+"}},
+		},
+		{
+			"single breakend", "chr1\t99\t100\t.\t-1\t-1\tsbnd1\t.\t-\t.",
+			100, ".N",
+			map[string][]string{"SVTYPE": {"BND"}, "CIPOS": {"0", "0"}},
+		},
+	}
+	for _, test := range tests {
+		variant := input.createVariant(test.line, header, "bedpe")
+		if variant.Pos != test.pos || variant.Alt != test.alt {
+			t.Errorf("%s: got %d %s, want %d %s", test.name, variant.Pos, variant.Alt, test.pos, test.alt)
+		}
+		if !reflect.DeepEqual(variant.Info, test.info) {
+			t.Errorf("%s: INFO = %v, want %v", test.name, variant.Info, test.info)
+		}
+
+		// The strands of the BEDPE output are the same as the strands of the input
+		_, _, strand1, strand2 := variant.secondBreakpoint()
+		if strands, ok := variant.Info["STRANDS"]; ok && strand1+strand2 != strands[0] {
+			t.Errorf("%s: the output strands %s%s don't match the input strands %s", test.name, strand1, strand2, strands[0])
+		}
+	}
+}
+
+func TestBedCreateVariant(t *testing.T) {
+	input := &ConfigInputFile{Svtype: "DEL", Columns: map[string]int{"svtype": 7}}
+	header := input.createHeader("bed")
+
+	tests := []struct {
+		name string
+		line string
+		pos  int64
+		alt  string
+		info map[string][]string
+	}{
+		{"default svtype", "chr1\t99\t200\tdel1\t.\t.", 99, "<DEL>", map[string][]string{"SVTYPE": {"DEL"}, "END": {"200"}, "SVLEN": {"-101"}}},
+		{"svtype column", "chr1\t99\t200\tdup1\t.\t.\tDUP", 99, "<DUP>", map[string][]string{"SVTYPE": {"DUP"}, "END": {"200"}, "SVLEN": {"101"}}},
+		{"start at the beginning of the contig", "chr1\t0\t10\tdel2\t.\t.", 1, "<DEL>", map[string][]string{"SVTYPE": {"DEL"}, "END": {"10"}, "SVLEN": {"-10"}}},
+	}
+	for _, test := range tests {
+		variant := input.createVariant(test.line, header, "bed")
+		if variant.Pos != test.pos || variant.Alt != test.alt {
+			t.Errorf("%s: got %d %s, want %d %s", test.name, variant.Pos, variant.Alt, test.pos, test.alt)
+		}
+		if !reflect.DeepEqual(variant.Info, test.info) {
+			t.Errorf("%s: INFO = %v, want %v", test.name, variant.Info, test.info)
+		}
+	}
+}
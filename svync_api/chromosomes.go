@@ -0,0 +1,187 @@
+package svync_api
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// The regex used to find the partner contig in the ALT of breakends
+var breakendAltRegex = regexp.MustCompile(`([\[\]])([^\[\]:]+):(\d+)([\[\]])`)
+
+// Create the chromosome mapping from the built-in aliases, the config and the mapping file given with --rename-chrs
+func (config *Config) createChromosomeMapping(Cctx *cli.Context) {
+	logger := newLogger()
+
+	mapping := map[string]string{}
+
+	primaryContigs := []string{}
+	for i := 1; i <= 22; i++ {
+		primaryContigs = append(primaryContigs, fmt.Sprint(i))
+	}
+	primaryContigs = append(primaryContigs, "X", "Y")
+
+	switch strings.ToLower(config.Chromosomes.Style) {
+	case "":
+	case "ucsc":
+		for _, contig := range primaryContigs {
+			mapping[contig] = "chr" + contig
+		}
+		mapping["MT"] = "chrM"
+	case "ensembl":
+		for _, contig := range primaryContigs {
+			mapping["chr"+contig] = contig
+		}
+		mapping["chrM"] = "MT"
+	default:
+		logger.Fatalf("The chromosome style '%s' is not supported, use 'ucsc' or 'ensembl'", config.Chromosomes.Style)
+	}
+
+	for old, new := range config.Chromosomes.Rename {
+		mapping[old] = new
+	}
+
+	if file := Cctx.String("rename-chrs"); file != "" {
+		mappingFile, err := os.Open(file)
+		if err != nil {
+			logger.Fatalf("Failed to open the chromosome mapping file: %v", err)
+		}
+		defer mappingFile.Close()
+
+		scanner := bufio.NewScanner(mappingFile)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				logger.Fatalf("Invalid line in the chromosome mapping file, expected two columns: '%s'", line)
+			}
+			mapping[fields[0]] = fields[1]
+		}
+		if err := scanner.Err(); err != nil {
+			logger.Fatalf("Failed to read the chromosome mapping file: %v", err)
+		}
+	}
+
+	config.Chromosomes.mapping = mapping
+}
+
+// Get the new name of a chromosome
+func (config *Config) renameChromosome(chromosome string) string {
+	if newChromosome, ok := config.Chromosomes.mapping[chromosome]; ok {
+		return newChromosome
+	}
+	return chromosome
+}
+
+// Rename the chromosomes of a standardized variant (CHROM, CHR2 and the partner contig of breakends)
+func (config *Config) renameChromosomes(variant *Variant) {
+	if len(config.Chromosomes.mapping) == 0 {
+		return
+	}
+
+	variant.Chromosome = config.renameChromosome(variant.Chromosome)
+
+	if chr2, ok := variant.Info["CHR2"]; ok {
+		for index, chromosome := range chr2 {
+			chr2[index] = config.renameChromosome(chromosome)
+		}
+	}
+
+	variant.Alt = breakendAltRegex.ReplaceAllStringFunc(variant.Alt, func(partner string) string {
+		groups := breakendAltRegex.FindStringSubmatch(partner)
+		return fmt.Sprintf("%s%s:%s%s", groups[1], config.renameChromosome(groups[2]), groups[3], groups[4])
+	})
+}
+
+// Compile the include and exclude patterns of the contigs
+func (config *Config) compileContigPatterns() {
+	logger := newLogger()
+
+	compile := func(patterns []string) []*regexp.Regexp {
+		regexes := []*regexp.Regexp{}
+		for _, pattern := range patterns {
+			regex, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				logger.Fatalf("Invalid contig pattern '%s': %v", pattern, err)
+			}
+			regexes = append(regexes, regex)
+		}
+		return regexes
+	}
+
+	config.Chromosomes.includeRegexes = compile(config.Chromosomes.Include)
+	config.Chromosomes.excludeRegexes = compile(config.Chromosomes.Exclude)
+}
+
+// Get the partner contigs of an input variant from its breakend ALT, or from the CHR2 INFO field of symbolic breakends and translocations
+func (variant *Variant) partnerContigs() []string {
+	contigs := []string{}
+	for _, groups := range breakendAltRegex.FindAllStringSubmatch(variant.Alt, -1) {
+		contigs = append(contigs, groups[2])
+	}
+	if svtype := variant.svtype(); len(contigs) == 0 && (svtype == "BND" || svtype == "TRA") {
+		for _, chromosome := range variant.Info["CHR2"] {
+			if chromosome != "" && chromosome != "." {
+				contigs = append(contigs, chromosome)
+			}
+		}
+	}
+	return contigs
+}
+
+// Check if a contig passes the include and exclude patterns
+func (config *Config) keepContig(contig string) bool {
+	if len(config.Chromosomes.includeRegexes) > 0 {
+		included := false
+		for _, regex := range config.Chromosomes.includeRegexes {
+			if regex.MatchString(contig) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, regex := range config.Chromosomes.excludeRegexes {
+		if regex.MatchString(contig) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check if all contigs of a standardized variant (CHROM, CHR2 and the partner contig of breakends) pass the include and exclude patterns
+// The partner contig is also taken from the input variant, because the config can rewrite the ALT and CHR2 of breakends (e.g. to <TRA>)
+func (config *Config) keepVariant(variant *Variant, standardizedVariant *Variant) bool {
+	if len(config.Chromosomes.includeRegexes) == 0 && len(config.Chromosomes.excludeRegexes) == 0 {
+		return true
+	}
+
+	contigs := []string{standardizedVariant.Chromosome}
+	for _, chromosome := range standardizedVariant.Info["CHR2"] {
+		if chromosome != "" {
+			contigs = append(contigs, chromosome)
+		}
+	}
+	for _, groups := range breakendAltRegex.FindAllStringSubmatch(standardizedVariant.Alt, -1) {
+		contigs = append(contigs, groups[2])
+	}
+	for _, partner := range variant.partnerContigs() {
+		contigs = append(contigs, config.renameChromosome(partner))
+	}
+
+	for _, contig := range contigs {
+		if !config.keepContig(contig) {
+			return false
+		}
+	}
+	return true
+}
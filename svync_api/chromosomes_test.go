@@ -0,0 +1,47 @@
+package svync_api
+
+import "testing"
+
+func TestKeepVariantWithRewrittenBreakendAlt(t *testing.T) {
+	Cctx := testContext()
+	config := parseConfig([]byte(`
+id: test
+alt:
+  value: <$INFO/SVTYPE>
+  alts:
+    BND: <TRA>
+chromosomes:
+  exclude: ["chrUn_.*"]
+`), Cctx)
+
+	header := newHeader()
+	for _, line := range []string{
+		`##fileformat=VCFv4.2`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+		`##INFO=<ID=CHR2,Number=1,Type=String,Description="Chromosome of the partner">`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO",
+	} {
+		header.parse(line)
+	}
+
+	tests := []struct {
+		name string
+		line string
+		keep bool
+	}{
+		{"breakend to excluded contig", "chr1\t100\tbnd1\tN\tN]chrUn_gl1:100]\t.\tPASS\tSVTYPE=BND;CHR2=chrUn_gl1", false},
+		{"symbolic breakend to excluded contig", "chr1\t100\tbnd2\tN\t<BND>\t.\tPASS\tSVTYPE=BND;CHR2=chrUn_gl1", false},
+		{"breakend to included contig", "chr1\t100\tbnd3\tN\tN]chr2:100]\t.\tPASS\tSVTYPE=BND;CHR2=chr2", true},
+		{"deletion on included contig", "chr1\t100\tdel1\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL", true},
+	}
+	for _, test := range tests {
+		variant := createVariant(test.line, header, Cctx)
+		standardizedVariant := variant.standardize(config, Cctx, 1)
+		if standardizedVariant.Alt == variant.Alt && variant.Info["SVTYPE"][0] == "BND" {
+			t.Fatalf("%s: the config should rewrite the ALT, got %s", test.name, standardizedVariant.Alt)
+		}
+		if keep := config.keepVariant(variant, standardizedVariant); keep != test.keep {
+			t.Errorf("%s: keepVariant() = %v, want %v", test.name, keep, test.keep)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package svync_api
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// A struct representing the parameters used to decide if two variants describe the same event
+type matchParameters struct {
+	// The maximum distance between the breakpoints
+	Distance int64
+
+	// The minimum reciprocal overlap of deletions, duplications, inversions and copy number variants (0-1)
+	Overlap float64
+
+	// The minimum ratio between the lengths of the variants (0-1)
+	SizeSimilarity float64
+}
+
+// Create the match parameters from the --distance, --overlap and --size-similarity arguments
+func newMatchParameters(Cctx *cli.Context) *matchParameters {
+	params := &matchParameters{
+		Distance:       Cctx.Int64("distance"),
+		Overlap:        Cctx.Float64("overlap"),
+		SizeSimilarity: Cctx.Float64("size-similarity"),
+	}
+	params.validate()
+	return params
+}
+
+// Validate the ranges of the match parameters
+func (params *matchParameters) validate() {
+	logger := newLogger()
+
+	if params.Distance < 0 {
+		logger.Fatalf("The distance (--distance) can't be negative")
+	}
+	if params.Overlap < 0 || params.Overlap > 1 {
+		logger.Fatalf("The overlap (--overlap) should be between 0 and 1")
+	}
+	if params.SizeSimilarity < 0 || params.SizeSimilarity > 1 {
+		logger.Fatalf("The size similarity (--size-similarity) should be between 0 and 1")
+	}
+}
+
+// Get the type of the variant, this is the least specific ALT key (e.g. DEL for <DEL:ME:ALU>)
+func (variant *Variant) svtype() string {
+	keys := variant.altKeys()
+	if len(keys) == 0 {
+		if isBreakendAllele(variant.Alt) {
+			return "BND"
+		}
+		return ""
+	}
+	return keys[len(keys)-1]
+}
+
+// Get the end position of the variant from END or from the length of the REF
+func (variant *Variant) end() int64 {
+	if end, err := infoInt(variant, "END"); err == nil {
+		return end
+	}
+	return variant.Pos + max(int64(len(variant.Ref))-1, 0)
+}
+
+// Get the absolute length of the variant from SVLEN or from the positions
+func (variant *Variant) length() int64 {
+	if length, err := infoInt(variant, "SVLEN"); err == nil {
+		return max(length, -length)
+	}
+	return variant.end() - variant.Pos
+}
+
+// Check if two variants describe the same event
+// The variants need the same type and chromosomes, and breakpoints within the distance
+func (params *matchParameters) match(a *Variant, b *Variant) bool {
+	svtype := a.svtype()
+	if svtype != b.svtype() || a.Chromosome != b.Chromosome || abs(a.Pos-b.Pos) > params.Distance {
+		return false
+	}
+
+	if svtype == "BND" || svtype == "TRA" {
+		chromA, posA, strandA, _ := a.secondBreakpoint()
+		chromB, posB, strandB, _ := b.secondBreakpoint()
+		return chromA == chromB && strandA == strandB && abs(posA-posB) <= params.Distance
+	}
+
+	if abs(a.end()-b.end()) > params.Distance {
+		return false
+	}
+
+	if params.SizeSimilarity > 0 {
+		lengthA, lengthB := a.length(), b.length()
+		if lengthA > 0 && lengthB > 0 && float64(min(lengthA, lengthB))/float64(max(lengthA, lengthB)) < params.SizeSimilarity {
+			return false
+		}
+	}
+
+	if params.Overlap > 0 && svtype != "INS" {
+		if reciprocalOverlap(a.Pos, a.end(), b.Pos, b.end()) < params.Overlap {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the reciprocal overlap of two regions (the overlap divided by the length of the longest region)
+func reciprocalOverlap(startA int64, endA int64, startB int64, endB int64) float64 {
+	overlap := min(endA, endB) - max(startA, startB) + 1
+	if overlap <= 0 {
+		return 0
+	}
+	return float64(overlap) / float64(max(endA-startA+1, endB-startB+1))
+}
+
+// Get the absolute value of an integer
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Read all variants of a standardized VCF or BCF file
+func readVariants(file string, Cctx *cli.Context) (*Header, []*Variant) {
+	logger := newLogger()
+
+	inputFormat := (&ConfigInputFile{}).inputFormat(file)
+	if isBedFormat(inputFormat) {
+		logger.Fatalf("The file %s is not a VCF or BCF file", file)
+	}
+
+	header := newHeader()
+	variants := []*Variant{}
+	readInput(file, inputFormat, func(line string) {
+		if line == "" {
+			return
+		}
+		if strings.HasPrefix(line, "#") {
+			header.parse(line)
+			return
+		}
+		variants = append(variants, createVariant(line, header, Cctx))
+	})
+	return header, variants
+}
+
+// Get the name of a file without its directory and extensions (e.g. /data/delly.vcf.gz => delly)
+func fileName(file string) string {
+	name := filepath.Base(file)
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Combine the headers of multiple files, the first definition of every field is used
+func unionHeaders(headers []*Header) *Header {
+	union := newHeader()
+	contigs := map[string]bool{}
+	for _, header := range headers {
+		if union.version == "" {
+			union.version = header.version
+		}
+		for id, info := range header.Info {
+			if _, ok := union.Info[id]; !ok {
+				union.Info[id] = info
+			}
+		}
+		for id, format := range header.Format {
+			if _, ok := union.Format[id]; !ok {
+				union.Format[id] = format
+			}
+		}
+		for id, alt := range header.Alt {
+			if _, ok := union.Alt[id]; !ok {
+				union.Alt[id] = alt
+			}
+		}
+		for id, filter := range header.Filter {
+			if _, ok := union.Filter[id]; !ok {
+				union.Filter[id] = filter
+			}
+		}
+		for _, contig := range header.Contig {
+			if !contigs[contig.Id] {
+				contigs[contig.Id] = true
+				union.Contig = append(union.Contig, contig)
+			}
+		}
+	}
+	return union
+}
+
+// Create a config that writes the fields of the header as they are
+// This is used to write files that are already standardized
+func headerConfig(header *Header) *Config {
+	config := &Config{
+		Info:       MapConfigInput{},
+		Format:     MapConfigInput{},
+		vcfVersion: "4.2",
+	}
+	if versionAtLeast(header.version, "4.2") {
+		config.vcfVersion = header.version
+	}
+	for id, info := range header.Info {
+		config.Info[id] = ConfigInput{Number: info.Number, Type: info.Type, Description: info.Description}
+	}
+	for id, format := range header.Format {
+		config.Format[id] = ConfigInput{Number: format.Number, Type: format.Type, Description: format.Description}
+	}
+	return config
+}
+
+// Sort the variants by the order of the contigs in the header and by position
+// Contigs that aren't in the header are sorted in natural order after the other contigs
+func sortVariants(variants []*Variant, header *Header) {
+	order := contigOrder(header)
+	sort.SliceStable(variants, func(i, j int) bool {
+		return compareVariants(variants[i], variants[j], order) < 0
+	})
+}
+
+// Get the index of every contig in the header
+func contigOrder(header *Header) map[string]int {
+	order := map[string]int{}
+	for index, contig := range header.Contig {
+		order[contig.Id] = index
+	}
+	return order
+}
+
+// Compare two variants by their contig and position
+func compareVariants(a *Variant, b *Variant, order map[string]int) int {
+	if a.Chromosome != b.Chromosome {
+		indexA, okA := order[a.Chromosome]
+		indexB, okB := order[b.Chromosome]
+		switch {
+		case okA && okB:
+			return indexA - indexB
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		if naturalLess(a.Chromosome, b.Chromosome) {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case a.Pos < b.Pos:
+		return -1
+	case a.Pos > b.Pos:
+		return 1
+	}
+	return 0
+}
+
+// Compare two strings in natural order, numbers in the strings are compared by their value (e.g. chr2 < chr10)
+func naturalLess(a string, b string) bool {
+	// Numbers with the same value but other digits (e.g. 01 and 1) are ordered by their digits when the rest is equal
+	tie := 0
+	for a != "" && b != "" {
+		numberA, restA := splitNumber(a)
+		numberB, restB := splitNumber(b)
+		if numberA != "" && numberB != "" {
+			valueA, _ := strconv.ParseUint(numberA, 10, 64)
+			valueB, _ := strconv.ParseUint(numberB, 10, 64)
+			if valueA != valueB {
+				return valueA < valueB
+			}
+			if tie == 0 {
+				tie = strings.Compare(numberA, numberB)
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return tie < 0
+}
+
+// Split the leading digits of a string from the rest of the string
+func splitNumber(value string) (string, string) {
+	index := strings.IndexFunc(value, func(letter rune) bool { return !unicode.IsDigit(letter) })
+	if index == -1 {
+		return value, ""
+	}
+	return value[:index], value[index:]
+}
@@ -0,0 +1,30 @@
+package svync_api
+
+import "testing"
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{"chr2", "chr10", true},
+		{"chr10", "chr2", false},
+		{"chr1", "chr1", false},
+		{"chr1", "chrX", true},
+		{"chrX", "chrY", true},
+		{"chr1", "chr1_random", true},
+		{"chr1_random", "chr1", false},
+		{"chr01", "chr1", true},
+		{"chr1", "chr01", false},
+		{"chr01_a", "chr1_b", true},
+		{"chr1_a", "chr01_b", true},
+		{"1", "2", true},
+		{"HLA-A*01:01", "HLA-A*01:02", true},
+	}
+	for _, test := range tests {
+		if got := naturalLess(test.a, test.b); got != test.want {
+			t.Errorf("naturalLess(%s, %s) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package svync_api
+
+import (
+	cli "github.com/urfave/cli/v2"
+)
+
+// Combine the standardized VCF files of multiple samples into one multi-sample VCF file
+func Combine(Cctx *cli.Context) {
+	logger := newLogger()
+
+	files := Cctx.Args().Slice()
+	if len(files) < 2 {
+		logger.Fatalf("The combine command needs at least two input files")
+	}
+	params := newMatchParameters(Cctx)
+
+	callsets := []*callset{}
+	headers := []*Header{}
+	sampleFiles := map[string]string{}
+	for _, file := range files {
+		header, variants := readVariants(file, Cctx)
+		set := &callset{Name: fileName(file), Header: header, Variants: variants, columns: map[string]string{}}
+		for _, sample := range header.Samples {
+			if other, ok := sampleFiles[sample]; ok {
+				logger.Fatalf("The sample %s is present in both %s and %s", sample, other, file)
+			}
+			sampleFiles[sample] = file
+			set.columns[sample] = sample
+		}
+		callsets = append(callsets, set)
+		headers = append(headers, header)
+	}
+
+	header := unionHeaders(headers)
+	if _, ok := header.Format["GT"]; !ok {
+		header.Format["GT"] = HeaderLineIdNumberTypeDescription{Id: "GT", Number: "1", Type: "String", Description: "Genotype"}
+	}
+	for _, set := range callsets {
+		header.Samples = append(header.Samples, set.Header.Samples...)
+	}
+
+	clusters := clusterCallsets(callsets, header, params)
+
+	config := headerConfig(header)
+	outputFile, stdout, closeOutput := createOutput(Cctx, config)
+	defer closeOutput()
+
+	writeHeader(config, Cctx, header, outputFile, stdout)
+	for _, cluster := range clusters {
+		writeVariant(config, Cctx, cluster.combine(callsets, header), outputFile, stdout)
+	}
+}
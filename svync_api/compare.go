@@ -0,0 +1,211 @@
+package svync_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// The lower bounds of the size bins used in the summary of the comparison
+var sizeBins = []int64{0, 50, 100, 1000, 10000}
+
+// A struct representing the counts and metrics of a comparison
+type compareStatistics struct {
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	FN        int     `json:"fn"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// A struct representing the statistics of a variant type
+type compareTypeSummary struct {
+	Total compareStatistics             `json:"total"`
+	Sizes map[string]*compareStatistics `json:"sizes"`
+}
+
+// A struct representing the summary of a comparison
+type compareSummary struct {
+	Total   compareStatistics              `json:"total"`
+	Svtypes map[string]*compareTypeSummary `json:"svtypes"`
+}
+
+// Compare a callset to a truth set and write the true positives, false positives, false negatives and a summary
+func Compare(Cctx *cli.Context) {
+	logger := newLogger()
+
+	params := newMatchParameters(Cctx)
+	truthHeader, truth := readVariants(Cctx.String("truth"), Cctx)
+	callsHeader, calls := readVariants(Cctx.String("calls"), Cctx)
+	sortVariants(truth, truthHeader)
+	sortVariants(calls, callsHeader)
+
+	matches := matchTruth(truth, calls, params)
+
+	// True positives are counted in the type and size bin of the variant of the truth set
+	summary := &compareSummary{Svtypes: map[string]*compareTypeSummary{}}
+	matchedTruth := map[*Variant]bool{}
+	truePositives := []*Variant{}
+	falsePositives := []*Variant{}
+	for _, call := range calls {
+		truthVariant, ok := matches[call]
+		if !ok {
+			falsePositives = append(falsePositives, call)
+			summary.add(call, func(stats *compareStatistics) { stats.FP++ })
+			continue
+		}
+		matchedTruth[truthVariant] = true
+		call.Info["TRUTHID"] = []string{truthVariant.Id}
+		truePositives = append(truePositives, call)
+		summary.add(truthVariant, func(stats *compareStatistics) { stats.TP++ })
+	}
+	falseNegatives := []*Variant{}
+	for _, truthVariant := range truth {
+		if !matchedTruth[truthVariant] {
+			falseNegatives = append(falseNegatives, truthVariant)
+			summary.add(truthVariant, func(stats *compareStatistics) { stats.FN++ })
+		}
+	}
+	summary.calculate()
+
+	// The true positives get the ID of the matching variant of the truth set
+	tpHeader := *callsHeader
+	tpHeader.Info = map[string]HeaderLineIdNumberTypeDescription{
+		"TRUTHID": {Id: "TRUTHID", Number: "1", Type: "String", Description: "ID of the matching variant in the truth set"},
+	}
+	for id, info := range callsHeader.Info {
+		tpHeader.Info[id] = info
+	}
+
+	prefix := Cctx.String("prefix")
+	writeVariants(fmt.Sprintf("%s.tp.vcf", prefix), &tpHeader, truePositives, Cctx)
+	writeVariants(fmt.Sprintf("%s.fp.vcf", prefix), callsHeader, falsePositives, Cctx)
+	writeVariants(fmt.Sprintf("%s.fn.vcf", prefix), truthHeader, falseNegatives, Cctx)
+
+	summaryJson, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		logger.Fatalf("Failed to create the summary: %v", err)
+	}
+	if err := os.WriteFile(fmt.Sprintf("%s.summary.json", prefix), append(summaryJson, '\n'), 0644); err != nil {
+		logger.Fatalf("Failed to write the summary: %v", err)
+	}
+	logger.Printf("TP: %d, FP: %d, FN: %d, precision: %.4f, recall: %.4f, F1: %.4f", summary.Total.TP, summary.Total.FP, summary.Total.FN, summary.Total.Precision, summary.Total.Recall, summary.Total.F1)
+}
+
+// Match every call to at most one variant of the truth set
+// A call is matched to the closest unmatched variant of the truth set that matches it
+// The truth set has to be sorted by position
+func matchTruth(truth []*Variant, calls []*Variant, params *matchParameters) map[*Variant]*Variant {
+	byChromosome := map[string][]*Variant{}
+	for _, variant := range truth {
+		byChromosome[variant.Chromosome] = append(byChromosome[variant.Chromosome], variant)
+	}
+
+	matched := map[*Variant]bool{}
+	matches := map[*Variant]*Variant{}
+	for _, call := range calls {
+		candidates := byChromosome[call.Chromosome]
+		start := sort.Search(len(candidates), func(i int) bool { return candidates[i].Pos >= call.Pos-params.Distance })
+
+		var best *Variant
+		bestDistance := int64(-1)
+		for _, candidate := range candidates[start:] {
+			if candidate.Pos > call.Pos+params.Distance {
+				break
+			}
+			if matched[candidate] || !params.match(candidate, call) {
+				continue
+			}
+			distance := abs(candidate.Pos-call.Pos) + abs(candidate.end()-call.end())
+			if best == nil || distance < bestDistance {
+				best = candidate
+				bestDistance = distance
+			}
+		}
+		if best != nil {
+			matched[best] = true
+			matches[call] = best
+		}
+	}
+	return matches
+}
+
+// Get the size bin of a variant (e.g. 100-1000), breakends have no size bin
+func sizeBin(variant *Variant) string {
+	svtype := variant.svtype()
+	if svtype == "BND" || svtype == "TRA" {
+		return "NA"
+	}
+	length := variant.length()
+	for index := len(sizeBins) - 1; index >= 0; index-- {
+		if length >= sizeBins[index] {
+			if index == len(sizeBins)-1 {
+				return fmt.Sprintf("%d+", sizeBins[index])
+			}
+			return fmt.Sprintf("%d-%d", sizeBins[index], sizeBins[index+1])
+		}
+	}
+	return "NA"
+}
+
+// Update the total statistics and the statistics of the type and size bin of the variant
+func (summary *compareSummary) add(variant *Variant, update func(stats *compareStatistics)) {
+	svtype := variant.svtype()
+	if _, ok := summary.Svtypes[svtype]; !ok {
+		summary.Svtypes[svtype] = &compareTypeSummary{Sizes: map[string]*compareStatistics{}}
+	}
+	typeSummary := summary.Svtypes[svtype]
+	bin := sizeBin(variant)
+	if _, ok := typeSummary.Sizes[bin]; !ok {
+		typeSummary.Sizes[bin] = &compareStatistics{}
+	}
+	update(&summary.Total)
+	update(&typeSummary.Total)
+	update(typeSummary.Sizes[bin])
+}
+
+// Calculate the precision, recall and F1 score of all statistics
+func (summary *compareSummary) calculate() {
+	summary.Total.calculate()
+	for _, typeSummary := range summary.Svtypes {
+		typeSummary.Total.calculate()
+		for _, stats := range typeSummary.Sizes {
+			stats.calculate()
+		}
+	}
+}
+
+// Calculate the precision, recall and F1 score from the counts
+func (stats *compareStatistics) calculate() {
+	if stats.TP+stats.FP > 0 {
+		stats.Precision = float64(stats.TP) / float64(stats.TP+stats.FP)
+	}
+	if stats.TP+stats.FN > 0 {
+		stats.Recall = float64(stats.TP) / float64(stats.TP+stats.FN)
+	}
+	if stats.Precision+stats.Recall > 0 {
+		stats.F1 = 2 * stats.Precision * stats.Recall / (stats.Precision + stats.Recall)
+	}
+}
+
+// Write the variants to a VCF file with the given header
+func writeVariants(file string, header *Header, variants []*Variant, Cctx *cli.Context) {
+	logger := newLogger()
+
+	outputFile, err := os.Create(file)
+	if err != nil {
+		logger.Fatalf("Failed to create the output file: %v", err)
+	}
+	defer outputFile.Close()
+
+	config := headerConfig(header)
+	writeHeader(config, Cctx, header, outputFile, false)
+	for _, variant := range variants {
+		variant.Header = header
+		writeLine(variant.String(config), outputFile, false)
+	}
+}
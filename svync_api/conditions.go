@@ -0,0 +1,97 @@
+package svync_api
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Evaluate a resolved condition and return its boolean value
+// Conditions can be combined with ` && ` and ` || ` and negated with a leading `!`
+// Comparisons are done with ` == `, ` != `, ` > `, ` >= `, ` < `, ` <= `, ` =~ ` and ` !~ `
+func evaluateCondition(condition string) bool {
+	for _, part := range strings.Split(condition, " || ") {
+		if evaluateAnd(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate a condition where all parts have to be true
+func evaluateAnd(condition string) bool {
+	for _, part := range strings.Split(condition, " && ") {
+		if !evaluateComparison(strings.TrimSpace(part)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate a single (negated) comparison or value
+func evaluateComparison(condition string) bool {
+	logger := newLogger()
+
+	if strings.HasPrefix(condition, "!") && !strings.HasPrefix(condition, "!=") {
+		return !evaluateComparison(strings.TrimSpace(condition[1:]))
+	}
+
+	for _, operator := range []string{"==", "!=", ">=", "<=", "=~", "!~", ">", "<"} {
+		split := strings.SplitN(condition, " "+operator+" ", 2)
+		if len(split) != 2 {
+			continue
+		}
+		left := strings.TrimSpace(split[0])
+		right := strings.TrimSpace(split[1])
+
+		if operator == "=~" || operator == "!~" {
+			regex, err := regexp.Compile(right)
+			if err != nil {
+				logger.Fatalf("Invalid regular expression '%s' in condition '%s': %v", right, condition, err)
+			}
+			return regex.MatchString(left) == (operator == "=~")
+		}
+
+		comparison := strings.Compare(left, right)
+		leftFloat, errLeft := strconv.ParseFloat(left, 64)
+		rightFloat, errRight := strconv.ParseFloat(right, 64)
+		if errLeft == nil && errRight == nil {
+			comparison = 0
+			if leftFloat < rightFloat {
+				comparison = -1
+			} else if leftFloat > rightFloat {
+				comparison = 1
+			}
+		}
+
+		switch operator {
+		case "==":
+			return comparison == 0
+		case "!=":
+			return comparison != 0
+		case ">=":
+			return comparison >= 0
+		case "<=":
+			return comparison <= 0
+		case ">":
+			return comparison > 0
+		case "<":
+			return comparison < 0
+		}
+	}
+
+	return isTruthy(condition)
+}
+
+// Check if a resolved value should be considered true
+// Empty values, missing values (.), `0` and `false` are considered false
+func isTruthy(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "." || strings.EqualFold(value, "false") {
+		return false
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number != 0
+	}
+	return true
+}
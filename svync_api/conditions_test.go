@@ -0,0 +1,79 @@
+package svync_api
+
+import "testing"
+
+func TestEvaluateCondition(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"", false},
+		{".", false},
+		{"0", false},
+		{"false", false},
+		{"true", true},
+		{"DEL", true},
+		{"0.5", true},
+		{"!0", true},
+		{"! true", false},
+		{"10 > 9", true},
+		{"10 > 9.5", true},
+		{"9 >= 10", false},
+		{"10 <= 10", true},
+		{"abc < abd", true},
+		{"DEL == DEL", true},
+		{"1.0 == 1", true},
+		{"DEL != DUP", true},
+		{"<DEL:ME> =~ ^<DEL", true},
+		{"<DUP> !~ ^<DEL", true},
+		{"DELDUP =~ ^(DEL|DUP){1,2}$", true},
+		{"DEL == DEL && 5 > 10", false},
+		{"DEL == DUP || 5 < 10", true},
+		{"DEL == DUP || 5 > 10 && true", false},
+		{"!DEL == DUP", true},
+	}
+	for _, test := range tests {
+		if got := evaluateCondition(test.condition); got != test.want {
+			t.Errorf("evaluateCondition(%q) = %v, want %v", test.condition, got, test.want)
+		}
+	}
+}
+
+func TestResolveIfFunction(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"~if:true,yes,no", "yes"},
+		{"~if:false,yes,no", "no"},
+		{"~if:,,true", "true"},
+		{"~if:DELDUP =~ ^(DEL|DUP){1,2}$,yes,no", "yes"},
+		{"~if:<DEL>,<DUP> =~ <DUP>,multi,single", "multi"},
+		{"~if:5 > 10,yes,~len:abc", "3"},
+		{"prefix_~if:1 == 1,yes,no", "prefix_yes"},
+	}
+	for _, test := range tests {
+		if got := resolveFunction(test.input, "~", nil); got != test.want {
+			t.Errorf("resolveFunction(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestContainsFunction(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"~len:$REF", true},
+		{"prefix_~sub:10,5", true},
+		{"INS =~ ^IN", false},
+		{"DEL !~ DUP", false},
+		{"~if:DEL =~ ^DEL,yes,no", true},
+		{"no function", false},
+	}
+	for _, test := range tests {
+		if got := containsFunction(test.input, "~"); got != test.want {
+			t.Errorf("containsFunction(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package svync_api
 
 import (
-	"log"
+	"fmt"
 	"os"
+	"path"
+	"sort"
+	"strings"
 
 	cli "github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v2"
@@ -10,11 +13,17 @@ import (
 
 // Read the configuration file, cast it to its struct and validate
 func ReadConfig(Cctx *cli.Context) *Config {
-	logger := log.New(os.Stderr, "", 0)
+	logger := newLogger()
 	configFile, err := os.ReadFile(Cctx.String("config"))
 	if err != nil {
 		logger.Fatalf("Failed to open the config file: %v", err)
 	}
+	return parseConfig(configFile, Cctx)
+}
+
+// Cast the contents of a configuration file to its struct and validate
+func parseConfig(configFile []byte, Cctx *cli.Context) *Config {
+	logger := newLogger()
 
 	var config Config
 
@@ -22,12 +31,75 @@ func ReadConfig(Cctx *cli.Context) *Config {
 		logger.Fatalf("Failed to parse the config file: %v", err)
 	}
 
-	config.defineMissing()
+	config.vcfVersion = vcfVersion(Cctx)
+	config.defineMissing(Cctx)
+	config.validate()
+	config.checkReservedFields()
+	config.createChromosomeMapping(Cctx)
+	config.compileContigPatterns()
+	config.reference = loadReference(Cctx)
+
+	if Cctx.Int64("to-literal") > 0 && config.reference == nil {
+		logger.Fatalf("Converting symbolic alleles to literal alleles (--to-literal) needs a reference FASTA file (--reference)")
+	}
+	if normalize := Cctx.String("normalize"); normalize != "" {
+		if normalize != "left" && normalize != "right" {
+			logger.Fatalf("The breakpoint normalization '%s' is not supported, use 'left' or 'right'", normalize)
+		}
+		if config.reference == nil {
+			logger.Fatalf("Normalizing the breakpoints (--normalize) needs a reference FASTA file (--reference)")
+		}
+	}
+
+	if !Cctx.Bool("two-pass") && len(config.statisticsFields()) > 0 {
+		logger.Fatalf("The config uses $STATS variables, these are only available in the two-pass mode (--two-pass)")
+	}
+	// The percentile ranks of the QUAL values are gathered in the same pass as the statistics
+	if config.Qual.Rescale.Method == "percentile" {
+		for _, value := range config.Qual.values() {
+			if statisticsRegex.MatchString(value) {
+				logger.Fatalf("The QUAL value '%s' uses $STATS variables, these can't be combined with the percentile rescaling of the QUAL field", value)
+			}
+		}
+	}
 	return &config
 }
 
+// Validate the configuration
+func (config *Config) validate() {
+	logger := newLogger()
+
+	config.Qual.Rescale.validate()
+	if config.Dedup != nil {
+		config.Dedup.validate(config)
+	}
+
+	for index, rule := range config.Rules {
+		if rule.When == "" {
+			logger.Fatalf("Rule %d has no 'when' condition", index+1)
+		}
+		for name := range rule.Then.Info {
+			if _, ok := config.Info[name]; !ok {
+				logger.Fatalf("Rule %d overrides the INFO field %s, which is not defined in the 'info' section", index+1, name)
+			}
+		}
+		for name := range rule.Then.Format {
+			if _, ok := config.Format[name]; !ok {
+				logger.Fatalf("Rule %d overrides the FORMAT field %s, which is not defined in the 'format' section", index+1, name)
+			}
+		}
+	}
+}
+
 // Define all missing mandatory fields
-func (config *Config) defineMissing() {
+func (config *Config) defineMissing(Cctx *cli.Context) {
+	if config.Info == nil {
+		config.Info = MapConfigInput{}
+	}
+	if config.Format == nil {
+		config.Format = MapConfigInput{}
+	}
+
 	// Info fields
 	if _, ok := config.Info["SVTYPE"]; !ok {
 		config.Info["SVTYPE"] = ConfigInput{
@@ -41,8 +113,8 @@ func (config *Config) defineMissing() {
 		config.Info["SVLEN"] = ConfigInput{
 			Value:       "$INFO/SVLEN",
 			Number:      "1",
-			Type:        "String",
-			Description: "Type of structural variant",
+			Type:        "Integer",
+			Description: "Difference in length between REF and ALT alleles",
 		}
 	}
 	if _, ok := config.Info["END"]; !ok {
@@ -60,8 +132,8 @@ func (config *Config) defineMissing() {
 		config.Info["CHR2"] = ConfigInput{
 			Value:       "",
 			Number:      "1",
-			Type:        "Integer",
-			Description: "End position of the variant described in this record",
+			Type:        "String",
+			Description: "Chromosome for the end position of the variant described in this record",
 			Alts: map[string]string{
 				"TRA": "$INFO/CHR2",
 			},
@@ -76,6 +148,55 @@ func (config *Config) defineMissing() {
 		}
 	}
 
+	if _, ok := config.Info["SVCLAIM"]; !ok && config.vcfVersion == "4.4" {
+		config.Info["SVCLAIM"] = ConfigInput{
+			Alts: ConfigAlts{
+				"DEL": "DJ",
+				"DUP": "DJ",
+				"CNV": "D",
+				"BND": "J",
+			},
+			Number:      "A",
+			Type:        "String",
+			Description: "Claim made by the structural variant call. Valid values are D, J, DJ for abundance, adjacency and both respectively",
+		}
+	}
+	if _, ok := config.Info["CN"]; !ok && config.vcfVersion == "4.4" {
+		config.Info["CN"] = ConfigInput{
+			Value: "$INFO/CN",
+			Defaults: map[string]string{
+				"$INFO/CN": "",
+			},
+			Number:      "A",
+			Type:        "Float",
+			Description: "Copy number of CNV/breakpoint",
+		}
+	}
+	if _, ok := config.Info["CICN"]; !ok && config.vcfVersion == "4.4" {
+		config.Info["CICN"] = ConfigInput{
+			Value: "$INFO/CICN",
+			Defaults: map[string]string{
+				"$INFO/CICN": "",
+			},
+			Number:      ".",
+			Type:        "Float",
+			Description: "Confidence interval around copy number",
+		}
+	}
+
+	// The inserted sequence is needed to convert insertions to symbolic alleles and back
+	if _, ok := config.Info["SVINSSEQ"]; !ok && (Cctx.Int64("to-symbolic") > 0 || Cctx.Int64("to-literal") > 0) {
+		config.Info["SVINSSEQ"] = ConfigInput{
+			Value: "$INFO/SVINSSEQ",
+			Defaults: map[string]string{
+				"$INFO/SVINSSEQ": "",
+			},
+			Number:      "1",
+			Type:        "String",
+			Description: "Inserted sequence",
+		}
+	}
+
 	// Format fields
 	if _, ok := config.Format["GT"]; !ok {
 		config.Format["GT"] = ConfigInput{
@@ -89,3 +210,66 @@ func (config *Config) defineMissing() {
 		}
 	}
 }
+
+// Unmarshal the alts, a list of keys can be used to give multiple ALTs the same value
+func (alts *ConfigAlts) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	raw := yaml.MapSlice{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	*alts = ConfigAlts{}
+	for _, item := range raw {
+		value := ""
+		if item.Value != nil {
+			value = fmt.Sprint(item.Value)
+		}
+		if keys, ok := item.Key.([]interface{}); ok {
+			for _, key := range keys {
+				(*alts)[fmt.Sprint(key)] = value
+			}
+			continue
+		}
+		(*alts)[fmt.Sprint(item.Key)] = value
+	}
+	return nil
+}
+
+// Get the value of the most specific key that matches one of the given ALT keys
+// The keys should be ordered from the most to the least specific
+// An exact match takes precedence over a wildcard match on the same key. Wildcards only match one level
+// of the ALT hierarchy, e.g. `INS:ME:*` matches `INS:ME:ALU`, but not `INS:ME`
+func (alts ConfigAlts) get(keys []string) (string, bool) {
+	if len(alts) == 0 {
+		return "", false
+	}
+
+	patterns := []string{}
+	for pattern := range alts {
+		if strings.ContainsAny(pattern, "*?[") {
+			patterns = append(patterns, pattern)
+		}
+	}
+	// Prefer the longest (most specific) pattern when multiple patterns match
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) == len(patterns[j]) {
+			return patterns[i] < patterns[j]
+		}
+		return len(patterns[i]) > len(patterns[j])
+	})
+
+	for _, key := range keys {
+		if value, ok := alts[key]; ok {
+			return value, true
+		}
+		for _, pattern := range patterns {
+			if strings.Count(pattern, ":") != strings.Count(key, ":") {
+				continue
+			}
+			if matched, _ := path.Match(pattern, key); matched {
+				return alts[pattern], true
+			}
+		}
+	}
+	return "", false
+}
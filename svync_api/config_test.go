@@ -0,0 +1,108 @@
+package svync_api
+
+import (
+	"os"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigAltsGet(t *testing.T) {
+	alts := ConfigAlts{}
+	err := yaml.Unmarshal([]byte(`
+INS: <INS>
+INS:ME: <INS:ME>
+INS:ME:*: <MEI>
+DEL:?: <DEL:SHORT>
+[DUP, CNV]: <CNV>
+BND: <TRA>
+`), &alts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		alt    string
+		svtype string
+		want   string
+		found  bool
+	}{
+		{"<INS>", "INS", "<INS>", true},
+		{"<INS:ME>", "INS", "<INS:ME>", true},
+		{"<INS:ME:ALU>", "INS", "<MEI>", true},
+		{"<INS:NOVEL>", "INS", "<INS>", true},
+		{"<DEL:X>", "DEL", "<DEL:SHORT>", true},
+		{"<DEL:XY>", "DEL", "", false},
+		{"<DUP:TANDEM>", "DUP", "<CNV>", true},
+		{"<CNV>", "CNV", "<CNV>", true},
+		{"N[chr2:100[", "BND", "<TRA>", true},
+		{"<INV>", "INV", "", false},
+	}
+	for _, test := range tests {
+		variant := &Variant{Alt: test.alt, Info: map[string][]string{"SVTYPE": {test.svtype}}}
+		got, found := alts.get(variant.altKeys())
+		if got != test.want || found != test.found {
+			t.Errorf("get(%s) = %q, %v, want %q, %v", test.alt, got, found, test.want, test.found)
+		}
+	}
+}
+
+func TestHeaderAltId(t *testing.T) {
+	config := &Config{Alt: ConfigSimpleInput{Alts: ConfigAlts{
+		"INS:ME:*": "<INS>",
+		"BND":      "<TRA>",
+		"DUP":      "<$INFO/SVTYPE>",
+		"INV":      "N",
+	}}}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"INS:ME:ALU", "INS"},
+		{"INS:ME", "INS:ME"},
+		{"BND", "TRA"},
+		{"DUP", "DUP"},
+		{"INV", "INV"},
+		{"DEL", "DEL"},
+	}
+	for _, test := range tests {
+		if got := config.headerAltId(test.id); got != test.want {
+			t.Errorf("headerAltId(%s) = %s, want %s", test.id, got, test.want)
+		}
+	}
+}
+
+func TestDellyConfigAlt(t *testing.T) {
+	Cctx := testContext()
+	data, err := os.ReadFile("../data/delly.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := parseConfig(data, Cctx)
+
+	header := newHeader()
+	for _, line := range []string{
+		`##fileformat=VCFv4.2`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+		`##INFO=<ID=END,Number=1,Type=Integer,Description="End position">`,
+		`##INFO=<ID=CIEND,Number=2,Type=Integer,Description="Confidence interval around END">`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO",
+	} {
+		header.parse(line)
+	}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"chr1\t100\tbnd1\tN\tN]chr2:100]\t.\tPASS\tSVTYPE=BND;END=100;CIEND=-5,5", "<TRA>"},
+		{"chr1\t100\tdel1\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL;END=200;CIEND=-5,5", "<DEL>"},
+	}
+	for _, test := range tests {
+		variant := createVariant(test.line, header, Cctx)
+		if got := variant.standardize(config, Cctx, 1).Alt; got != test.want {
+			t.Errorf("ALT of %s = %s, want %s", variant.Id, got, test.want)
+		}
+	}
+}
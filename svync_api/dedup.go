@@ -0,0 +1,145 @@
+package svync_api
+
+import (
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// A struct representing a standardized variant that waits for the deduplication
+type dedupVariant struct {
+	// The standardized variant
+	variant *Variant
+
+	// The ID of the variant in the input file
+	inputId string
+
+	// The resolved score of the variant, variants without a numeric score get -Inf
+	score float64
+
+	// The position of the variant in the output, used to keep the order of the input
+	index int
+}
+
+// Validate the deduplication configuration and define its INFO field
+func (dedup *ConfigDedup) validate(config *Config) {
+	logger := newLogger()
+
+	if dedup.Distance < 0 {
+		logger.Fatalf("The 'distance' of the dedup section can't be negative")
+	}
+	if dedup.Overlap < 0 || dedup.Overlap > 1 {
+		logger.Fatalf("The 'overlap' of the dedup section should be between 0 and 1")
+	}
+	if dedup.Similarity < 0 || dedup.Similarity > 1 {
+		logger.Fatalf("The 'similarity' of the dedup section should be between 0 and 1")
+	}
+	if dedup.Score == "" {
+		dedup.Score = "$QUAL"
+	}
+	// The score is resolved once per variant, not per sample
+	if strings.Contains(dedup.Score, "$FORMAT/") {
+		logger.Fatalf("The 'score' of the dedup section can't use FORMAT fields, got '%s'", dedup.Score)
+	}
+	if dedup.Info == "" {
+		dedup.Info = "DEDUP_IDS"
+	}
+	if _, ok := config.Info[dedup.Info]; !ok {
+		// The field has no value, it's only added to the variants by the deduplication
+		config.Info[dedup.Info] = ConfigInput{
+			Number:      ".",
+			Type:        "String",
+			Description: "IDs of the duplicate records in the input file that were collapsed into this variant",
+		}
+	}
+}
+
+// Keep the standardized variant until all variants have been read
+func (dedup *ConfigDedup) add(variant *Variant, standardizedVariant *Variant, Cctx *cli.Context, config *Config) {
+	score, err := strconv.ParseFloat(ResolveValue(dedup.Score, variant, nil, Cctx, config), 64)
+	if err != nil {
+		score = math.Inf(-1)
+	}
+	dedup.variants = append(dedup.variants, &dedupVariant{
+		variant: standardizedVariant,
+		inputId: variant.Id,
+		score:   score,
+		index:   len(dedup.variants),
+	})
+}
+
+// Cluster the duplicate variants and write the variant with the highest score of each cluster
+// The variants are written in the order of the input file
+func (dedup *ConfigDedup) flush(config *Config, Cctx *cli.Context, header *Header, file *os.File, stdout bool) {
+	params := &matchParameters{
+		Distance:       dedup.Distance,
+		Overlap:        dedup.Overlap,
+		SizeSimilarity: dedup.Similarity,
+	}
+
+	sorted := make([]*dedupVariant, len(dedup.variants))
+	copy(sorted, dedup.variants)
+	order := contigOrder(header)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareVariants(sorted[i].variant, sorted[j].variant, order) < 0
+	})
+
+	// Every cluster is compared to its first variant, like the clusters of the merge command
+	clusters := [][]*dedupVariant{}
+	active := []int{}
+	for _, current := range sorted {
+		remaining := []int{}
+		for _, index := range active {
+			first := clusters[index][0].variant
+			if first.Chromosome == current.variant.Chromosome && current.variant.Pos-first.Pos <= params.Distance {
+				remaining = append(remaining, index)
+			}
+		}
+		active = remaining
+
+		match := -1
+		for _, index := range active {
+			if params.match(clusters[index][0].variant, current.variant) {
+				match = index
+				break
+			}
+		}
+		if match == -1 {
+			match = len(clusters)
+			clusters = append(clusters, []*dedupVariant{})
+			active = append(active, match)
+		}
+		clusters[match] = append(clusters[match], current)
+	}
+
+	// The first variant in the input wins when the scores are equal
+	kept := []*dedupVariant{}
+	for _, cluster := range clusters {
+		best := cluster[0]
+		for _, candidate := range cluster[1:] {
+			if candidate.score > best.score || (candidate.score == best.score && candidate.index < best.index) {
+				best = candidate
+			}
+		}
+		collapsed := []string{}
+		for _, candidate := range cluster {
+			if candidate != best {
+				collapsed = append(collapsed, candidate.inputId)
+			}
+		}
+		if len(collapsed) > 0 {
+			best.variant.Info[dedup.Info] = collapsed
+		}
+		kept = append(kept, best)
+	}
+
+	sort.Slice(kept, func(i, j int) bool { return kept[i].index < kept[j].index })
+	for _, current := range kept {
+		outputVariant(config, Cctx, current.variant, file, stdout)
+	}
+	dedup.variants = nil
+}
@@ -0,0 +1,86 @@
+package svync_api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDedupFlush(t *testing.T) {
+	Cctx := testContext()
+	config := parseConfig([]byte(`
+id: test
+dedup:
+  distance: 10
+  overlap: 0.5
+`), Cctx)
+
+	header := newHeader()
+	for _, line := range []string{
+		`##fileformat=VCFv4.2`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+		`##INFO=<ID=END,Number=1,Type=Integer,Description="End position">`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO",
+	} {
+		header.parse(line)
+	}
+
+	for _, line := range []string{
+		"chr1\t100\tdel1\tN\t<DEL>\t10\tPASS\tSVTYPE=DEL;END=200",
+		// A duplicate of del1 with a higher score
+		"chr1\t105\tdel2\tN\t<DEL>\t30\tPASS\tSVTYPE=DEL;END=205",
+		// The end is too far from the end of del1
+		"chr1\t108\tdel3\tN\t<DEL>\t50\tPASS\tSVTYPE=DEL;END=600",
+		// Another type
+		"chr1\t100\tdup1\tN\t<DUP>\t5\tPASS\tSVTYPE=DUP;END=200",
+		// Another chromosome
+		"chr2\t100\tdel4\tN\t<DEL>\t1\tPASS\tSVTYPE=DEL;END=200",
+		// Duplicates without a score, the first variant is kept
+		"chr1\t300\tins1\tN\t<INS>\t.\tPASS\tSVTYPE=INS",
+		"chr1\t302\tins2\tN\t<INS>\t.\tPASS\tSVTYPE=INS",
+	} {
+		variant := createVariant(line, header, Cctx)
+		config.Dedup.add(variant, variant, Cctx, config)
+	}
+
+	path := filepath.Join(t.TempDir(), "dedup.vcf")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Dedup.flush(config, Cctx, header, file, false)
+	file.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	// The kept variants are written in the order of the input
+	want := []struct {
+		id        string
+		collapsed string
+	}{
+		{"del2", "del1"},
+		{"del3", ""},
+		{"dup1", ""},
+		{"del4", ""},
+		{"ins1", "ins2"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("wrote %d variants, want %d:\n%s", len(lines), len(want), data)
+	}
+	for index, test := range want {
+		fields := strings.Split(lines[index], "\t")
+		if fields[2] != test.id {
+			t.Errorf("variant %d has ID %s, want %s", index, fields[2], test.id)
+		}
+		hasCollapsed := strings.Contains(fields[7], "DEDUP_IDS=")
+		if test.collapsed == "" && hasCollapsed {
+			t.Errorf("%s: INFO %s shouldn't have DEDUP_IDS", test.id, fields[7])
+		} else if test.collapsed != "" && !strings.Contains(fields[7], "DEDUP_IDS="+test.collapsed) {
+			t.Errorf("%s: INFO %s should have DEDUP_IDS=%s", test.id, fields[7], test.collapsed)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"bytes"
 	"io"
-	"log"
 	"os"
 	"regexp"
 	"strconv"
@@ -16,32 +15,193 @@ import (
 
 // Read the VCF file and return it as a VCF struct
 func Execute(Cctx *cli.Context, config *Config) {
-	logger := log.New(os.Stderr, "", 0)
-
 	file := Cctx.String("input")
-	inputVcf, err := os.Open(file)
-	if err != nil {
-		logger.Fatal(err)
-	}
-	defer inputVcf.Close()
+	inputFormat := config.Input.inputFormat(file)
 	header := newHeader()
+	if isBedFormat(inputFormat) {
+		header = config.Input.createHeader(inputFormat)
+	}
+	if Cctx.Bool("two-pass") || config.Qual.Rescale.Method == "percentile" {
+		config.gatherStatistics(file, header, inputFormat, Cctx)
+	}
 	breakEndVariants := &map[string]Variant{}
 	headerIsMade := false
 	variantCount := 0
 
+	outputFile, stdout, closeOutput := createOutput(Cctx, config)
+	defer closeOutput()
+
+	if Cctx.Bool("sort") {
+		config.sorter = newVariantSorter(Cctx.Int("sort-buffer"))
+		// The temporary files are also removed when the standardization fails
+		defer config.sorter.cleanup()
+	}
+
+	readInput(file, inputFormat, func(line string) {
+		parseLine(
+			line,
+			header,
+			breakEndVariants,
+			config,
+			Cctx,
+			&headerIsMade,
+			outputFile,
+			stdout,
+			&variantCount,
+			inputFormat,
+		)
+	})
+
+	if !headerIsMade {
+		writeHeader(config, Cctx, header, outputFile, stdout)
+		headerIsMade = true
+	}
+
+	if config.Dedup != nil {
+		config.Dedup.flush(config, Cctx, header, outputFile, stdout)
+	}
+	if config.sorter != nil {
+		config.sorter.flush(config, Cctx, outputFile, stdout)
+	}
+}
+
+// Create the output file given with --output, the output is written to stdout when it isn't given
+// Returns the output file, whether stdout is used and a function that closes the output
+func createOutput(Cctx *cli.Context, config *Config) (*os.File, bool, func()) {
+	if Cctx.String("split-by") != "" {
+		config.splitter = newOutputSplitter(Cctx)
+		return nil, false, config.splitter.close
+	}
+
 	stdout := true
 	var outputFile *os.File
-	if Cctx.String("output") != "" {
+	closeFile := func() {}
+	if output := Cctx.String("output"); output != "" {
 		stdout = false
-		outputFile, err = os.Create(Cctx.String("output"))
+		outputFile, closeFile = createFile(output, outputFormat(Cctx))
+		config.outputPath = output
+	}
+	if outputFormat(Cctx) == "bcf" {
+		config.bcf = newBcfWriter(outputFile, stdout)
+	}
+
+	return outputFile, stdout, func() {
+		if config.bcf != nil {
+			config.bcf.close()
+		}
+		closeFile()
+	}
+}
+
+// Remove the output files created by createOutput, used to clean up the output of a failed run
+func (config *Config) removeOutput() {
+	if config.splitter != nil {
+		config.splitter.remove()
+	}
+	if config.outputPath != "" {
+		os.Remove(config.outputPath)
+	}
+}
+
+// Create an output file, text files with the .gz extension are compressed with BGZF
+// Returns the file to write to and a function that closes the output
+func createFile(path string, format string) (*os.File, func()) {
+	logger := newLogger()
+
+	outputFile, err := os.Create(path)
+	if err != nil {
+		logger.Fatalf("Failed to create the output file: %v", err)
+	}
+	closeFile := func() {
+		if err := outputFile.Close(); err != nil {
+			logger.Fatalf("Failed to write the output file: %v", err)
+		}
+	}
+	// BCF files are always compressed by the BCF writer
+	if !strings.HasSuffix(path, ".gz") || format == "bcf" {
+		return outputFile, closeFile
+	}
+
+	// The lines are written to a pipe so the output can be used like an uncompressed file
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		logger.Fatalf("Failed to create the output file: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		bgWriter := bgzf.NewWriter(outputFile, 1)
+		_, err := io.Copy(bgWriter, reader)
 		if err != nil {
-			logger.Fatalf("Failed to create the output file: %v", err)
+			// Keep reading the pipe so the writes don't block, the error is reported when the output is closed
+			io.Copy(io.Discard, reader)
+		}
+		if closeErr := bgWriter.Close(); err == nil {
+			err = closeErr
+		}
+		if closeErr := outputFile.Close(); err == nil {
+			err = closeErr
+		}
+		reader.Close()
+		done <- err
+	}()
+
+	return writer, func() {
+		writer.Close()
+		if err := <-done; err != nil {
+			logger.Fatalf("Failed to write the output file: %v", err)
+		}
+	}
+}
+
+// Get the output format from --output-format or from the extension of the output file
+func outputFormat(Cctx *cli.Context) string {
+	logger := newLogger()
+
+	format := strings.ToLower(Cctx.String("output-format"))
+	if format == "" {
+		output := strings.TrimSuffix(Cctx.String("output"), ".gz")
+		format = "vcf"
+		if strings.HasSuffix(output, ".bedpe") {
+			format = "bedpe"
+		} else if strings.HasSuffix(output, ".jsonl") {
+			format = "jsonl"
+		} else if strings.HasSuffix(output, ".tsv") {
+			format = "tsv"
+		} else if strings.HasSuffix(output, ".bcf") {
+			format = "bcf"
 		}
-		defer outputFile.Close()
 	}
 
+	switch format {
+	case "vcf", "bcf", "bedpe", "jsonl", "tsv":
+		return format
+	}
+	logger.Fatalf("The output format '%s' is not supported, use 'vcf', 'bcf', 'bedpe', 'jsonl' or 'tsv'", format)
+	return ""
+}
+
+// Read the input file and call the function for every line
+// The records of BCF files are converted to VCF lines
+func readInput(file string, inputFormat string, parse func(line string)) {
+	if inputFormat == "bcf" {
+		readBcfLines(file, parse)
+		return
+	}
+	readLines(file, parse)
+}
+
+// Read the (bgzipped) file and call the function for every line
+func readLines(file string, parse func(line string)) {
+	logger := newLogger()
+
+	inputFile, err := os.Open(file)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer inputFile.Close()
+
 	if strings.HasSuffix(file, ".gz") {
-		bgReader, err := bgzf.NewReader(inputVcf, 1)
+		bgReader, err := bgzf.NewReader(inputFile, 1)
 		if err != nil {
 			logger.Fatal(err)
 		}
@@ -56,46 +216,20 @@ func Execute(Cctx *cli.Context, config *Config) {
 				logger.Fatal(string(b[:]))
 			}
 
-			parseLine(
-				string(bytes.TrimSpace(b[:])),
-				header,
-				breakEndVariants,
-				config,
-				Cctx,
-				&headerIsMade,
-				outputFile,
-				stdout,
-				&variantCount,
-			)
+			parse(string(bytes.TrimSpace(b[:])))
 		}
 	} else {
-		scanner := bufio.NewScanner(inputVcf)
+		scanner := bufio.NewScanner(inputFile)
 		const maxCapacity = 8 * 1000000 // 8 MB
 		scanner.Buffer(make([]byte, maxCapacity), maxCapacity)
 		for scanner.Scan() {
-			parseLine(
-				scanner.Text(),
-				header,
-				breakEndVariants,
-				config,
-				Cctx,
-				&headerIsMade,
-				outputFile,
-				stdout,
-				&variantCount,
-			)
+			parse(scanner.Text())
 		}
 
 		if err := scanner.Err(); err != nil {
 			logger.Fatal(err)
 		}
 	}
-
-	if !headerIsMade {
-		writeHeader(config, Cctx, header, outputFile, stdout)
-		headerIsMade = true
-	}
-
 }
 
 // readBgzipLine reads a line from a bgzip file
@@ -131,7 +265,14 @@ func parseLine(
 	outputFile *os.File,
 	stdout bool,
 	variantCount *int,
+	inputFormat string,
 ) {
+	if line == "" {
+		return
+	}
+	if isBedFormat(inputFormat) && isBedComment(line) {
+		return
+	}
 	if strings.HasPrefix(line, "#") {
 		header.parse(line)
 	} else {
@@ -140,7 +281,15 @@ func parseLine(
 			*headerIsMade = true
 		}
 		// id := strings.Split(line, "\t")[2]
-		variant := createVariant(line, header, Cctx)
+		var variant *Variant
+		if isBedFormat(inputFormat) {
+			variant = config.Input.createVariant(line, header, inputFormat)
+		} else {
+			variant = createVariant(line, header, Cctx)
+		}
+		if threshold := Cctx.Int64("to-symbolic"); threshold > 0 {
+			variant.toSymbolic(threshold)
+		}
 
 		// TODO continue work on this later
 		// Convert breakends to breakpoints if the --to-breakpoint flag is set
@@ -163,7 +312,7 @@ func parseLine(
 
 // Parse the line and add it to the Variant struct
 func createVariant(line string, header *Header, Cctx *cli.Context) *Variant {
-	logger := log.New(os.Stderr, "", 0)
+	logger := newLogger()
 
 	variant := new(Variant)
 	variant.Header = header
@@ -185,16 +334,22 @@ func createVariant(line string, header *Header, Cctx *cli.Context) *Variant {
 	variant.Info = map[string][]string{}
 	info := strings.Split(data[7], ";")
 	for _, i := range info {
-		split := strings.Split(i, "=")
+		split := strings.SplitN(i, "=", 2)
 		field := split[0]
-		value := ""
-		if len(split) > 1 {
-			value = split[1]
+		if len(split) == 1 {
+			// Fields without a value are flags
+			variant.Info[field] = []string{}
+			continue
 		}
-		variant.Info[field] = parseInfoFormat(field, value, variant.Header.Info, Cctx)
+		value := split[1]
+		variant.Info[field] = header.decodeValues(parseInfoFormat(field, value, variant.Header.Info, Cctx))
 	}
 
 	variant.Format = map[string]VariantFormat{}
+	// Files without samples have no FORMAT column
+	if len(data) < 9 {
+		return variant
+	}
 	formatHeaders := strings.Split(data[8], ":")
 	formatValues := data[9:]
 	for index, value := range formatValues {
@@ -205,7 +360,7 @@ func createVariant(line string, header *Header, Cctx *cli.Context) *Variant {
 		}
 		for idx, val := range strings.Split(value, ":") {
 			header := formatHeaders[idx]
-			variant.Format[sample].Content[header] = parseInfoFormat(header, val, variant.Header.Format, Cctx)
+			variant.Format[sample].Content[header] = variant.Header.decodeValues(parseInfoFormat(header, val, variant.Header.Format, Cctx))
 		}
 	}
 
@@ -215,7 +370,7 @@ func createVariant(line string, header *Header, Cctx *cli.Context) *Variant {
 
 // Parse the value of the INFO or FORMAT field and return it as a slice of strings
 func parseInfoFormat(header string, value string, infoFormatLines map[string]HeaderLineIdNumberTypeDescription, Cctx *cli.Context) []string {
-	logger := log.New(os.Stderr, "", 0)
+	logger := newLogger()
 	headerLine := infoFormatLines[header]
 	if headerLine == (HeaderLineIdNumberTypeDescription{}) {
 		if !Cctx.Bool("mute-warnings") {
@@ -243,7 +398,10 @@ func parseInfoFormat(header string, value string, infoFormatLines map[string]Hea
 // Parse the header line and add it to the Header struct
 func (header *Header) parse(line string) {
 	if strings.HasPrefix(line, "#CHROM") {
-		header.Samples = strings.Split(line, "\t")[9:]
+		// Files without samples have no FORMAT column
+		if columns := strings.Split(line, "\t"); len(columns) > 9 {
+			header.Samples = columns[9:]
+		}
 		return
 	}
 
@@ -251,6 +409,9 @@ func (header *Header) parse(line string) {
 	matches := r.FindStringSubmatch(line)
 
 	if len(matches) == 0 {
+		if strings.HasPrefix(line, "##fileformat=VCFv") {
+			header.version = strings.TrimPrefix(line, "##fileformat=VCFv")
+		}
 		if header.Other == nil {
 			header.Other = []string{}
 		}
@@ -264,6 +425,7 @@ func (header *Header) parse(line string) {
 
 	switch headerType {
 	case "INFO":
+		header.dictionary = addToDictionary(header.dictionary, contentMap["id"], contentMap["idx"])
 		header.Info[contentMap["id"]] = HeaderLineIdNumberTypeDescription{
 			Id:          contentMap["id"],
 			Number:      contentMap["number"],
@@ -271,6 +433,7 @@ func (header *Header) parse(line string) {
 			Description: contentMap["description"],
 		}
 	case "FORMAT":
+		header.dictionary = addToDictionary(header.dictionary, contentMap["id"], contentMap["idx"])
 		header.Format[contentMap["id"]] = HeaderLineIdNumberTypeDescription{
 			Id:          contentMap["id"],
 			Number:      contentMap["number"],
@@ -283,18 +446,22 @@ func (header *Header) parse(line string) {
 			Description: contentMap["description"],
 		}
 	case "FILTER":
+		header.dictionary = addToDictionary(header.dictionary, contentMap["id"], contentMap["idx"])
 		header.Filter[contentMap["id"]] = HeaderLineIdDescription{
 			Id:          contentMap["id"],
 			Description: contentMap["description"],
 		}
 	case "contig":
+		// The length is optional, contigs without a (valid) length get a length of 0
 		length, err := strconv.ParseInt(contentMap["length"], 0, 64)
 		if err != nil {
-			log.Fatalf("Could not convert contig length to an integer: %v", err)
+			length = 0
 		}
-		header.Contig = append(header.Contig, HeaderLineIdLength{
-			Id:     contentMap["id"],
-			Length: length,
+		header.contigDictionary = addToDictionary(header.contigDictionary, contentMap["id"], contentMap["idx"])
+		header.Contig = append(header.Contig, HeaderLineContig{
+			Id:         contentMap["id"],
+			Length:     length,
+			Attributes: convertLineToAttributes(content),
 		})
 	}
 
@@ -303,17 +470,26 @@ func (header *Header) parse(line string) {
 // convertLineToMap converts the header line contents to a map suitable to transform to a struct
 func convertLineToMap(line string) map[string]string {
 	data := map[string]string{}
+	for _, attribute := range convertLineToAttributes(line) {
+		data[strings.ToLower(attribute.Key)] = attribute.Value
+	}
+	return data
+}
+
+// convertLineToAttributes converts the header line contents to a list of key-value pairs in their original order
+func convertLineToAttributes(line string) []HeaderLineAttribute {
+	attributes := []HeaderLineAttribute{}
 	word := ""
 	key := ""
 	quote := ""
 	for _, letter := range strings.Split(line, "") {
 		if quote == "" {
-			if letter == "=" {
-				key = strings.ToLower(word)
+			if letter == "=" && key == "" {
+				key = word
 				word = ""
 				continue
-			} else if letter == "," && quote == "" {
-				data[key] = word
+			} else if letter == "," {
+				attributes = append(attributes, HeaderLineAttribute{Key: key, Value: word})
 				key = ""
 				word = ""
 				continue
@@ -324,13 +500,13 @@ func convertLineToMap(line string) map[string]string {
 
 		if letter == quote {
 			quote = ""
-		} else if letter == "\"" || letter == "'" {
+		} else if quote == "" && (letter == "\"" || letter == "'") {
 			quote = letter
 		}
 	}
-	data[key] = word
+	attributes = append(attributes, HeaderLineAttribute{Key: key, Value: word})
 
-	return data
+	return attributes
 }
 
 // Create a new header struct
@@ -340,8 +516,10 @@ func newHeader() *Header {
 		Format:  map[string]HeaderLineIdNumberTypeDescription{},
 		Alt:     map[string]HeaderLineIdDescription{},
 		Filter:  map[string]HeaderLineIdDescription{},
-		Contig:  []HeaderLineIdLength{},
+		Contig:  []HeaderLineContig{},
 		Other:   []string{},
 		Samples: []string{},
+		// PASS is always the first entry in the dictionary of strings
+		dictionary: []string{"PASS"},
 	}
 }
@@ -0,0 +1,104 @@
+package svync_api
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/biogo/hts/bgzf"
+	cli "github.com/urfave/cli/v2"
+)
+
+// Create a context with the default values of the arguments used by the standardization
+func testContext() *cli.Context {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("vcf-version", "4.2", "")
+	flags.Bool("mute-warnings", true, "")
+	return cli.NewContext(cli.NewApp(), flags, nil)
+}
+
+func TestCreateVariantInfoValues(t *testing.T) {
+	Cctx := testContext()
+	header := newHeader()
+	for _, line := range []string{
+		`##fileformat=VCFv4.2`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+		`##INFO=<ID=IMPRECISE,Number=0,Type=Flag,Description="Imprecise structural variation">`,
+		`##INFO=<ID=NOTE,Number=1,Type=String,Description="A free text note">`,
+		`##INFO=<ID=CIPOS,Number=2,Type=Integer,Description="Confidence interval around POS">`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO",
+	} {
+		header.parse(line)
+	}
+
+	tests := []struct {
+		name  string
+		info  string
+		field string
+		want  []string
+	}{
+		{"plain value", "SVTYPE=DEL", "SVTYPE", []string{"DEL"}},
+		{"value with an equals sign", "NOTE=a=b", "NOTE", []string{"a=b"}},
+		{"value with multiple equals signs", "NOTE=key=value=x", "NOTE", []string{"key=value=x"}},
+		{"list value", "CIPOS=-10,10", "CIPOS", []string{"-10", "10"}},
+		{"flag", "IMPRECISE", "IMPRECISE", []string{}},
+	}
+	for _, test := range tests {
+		variant := createVariant("chr1\t100\tvar1\tN\t<DEL>\t.\tPASS\t"+test.info, header, Cctx)
+		if got := variant.Info[test.field]; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: INFO/%s = %q, want %q", test.name, test.field, got, test.want)
+		}
+	}
+}
+
+func TestCreateFileCompressesGzOutput(t *testing.T) {
+	dir := t.TempDir()
+	// Write more than the buffer of a pipe to make sure the writes don't block
+	lines := ""
+	for len(lines) < 200000 {
+		lines += "chr1\t100\tvar1\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL\n"
+	}
+
+	tests := []struct {
+		name       string
+		format     string
+		compressed bool
+	}{
+		{"out.vcf", "vcf", false},
+		{"out.vcf.gz", "vcf", true},
+		{"out.tsv.gz", "tsv", true},
+		{"out.bcf.gz", "bcf", false},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		file, closeFile := createFile(path, test.format)
+		if _, err := file.WriteString(lines); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		closeFile()
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reader io.Reader = file
+		if test.compressed {
+			bgReader, err := bgzf.NewReader(file, 1)
+			if err != nil {
+				t.Fatalf("%s: not a BGZF file: %v", test.name, err)
+			}
+			reader = bgReader
+		}
+		data, err := io.ReadAll(reader)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if string(data) != lines {
+			t.Errorf("%s: read %d bytes, want %d", test.name, len(data), len(lines))
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package svync_api
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// Standardize the FILTER field of a variant using the config
+func (config *Config) standardizeFilter(variant *Variant, Cctx *cli.Context) string {
+	filters := []string{}
+	for _, filter := range strings.Split(variant.Filter, ";") {
+		if filter == "" || filter == "." || filter == "PASS" {
+			continue
+		}
+		if newFilter, ok := config.Filter.Map[filter]; ok {
+			filter = newFilter
+		}
+		if filter != "" && !slices.Contains(filters, filter) {
+			filters = append(filters, filter)
+		}
+	}
+
+	// Add all filters of which the value resolves to true
+	for _, name := range sortedKeys(config.Filter.Filters) {
+		value := config.Filter.Filters[name].Value
+		if value == "" || slices.Contains(filters, name) {
+			continue
+		}
+		if evaluateCondition(ResolveValue(value, variant, nil, Cctx, config)) {
+			filters = append(filters, name)
+		}
+	}
+
+	if len(filters) > 0 {
+		return strings.Join(filters, ";")
+	}
+	if variant.Filter == "." {
+		return "."
+	}
+	return "PASS"
+}
+
+// Get all filters that can be present in the output with their descriptions
+// The filters are sorted on their ID, with PASS as the first filter
+func (config *Config) filterHeaderLines(header *Header) []HeaderLineIdDescription {
+	descriptions := map[string]string{
+		"PASS": "All filters passed",
+	}
+	addFilter := func(id string, description string) {
+		if id == "" {
+			return
+		}
+		if _, ok := descriptions[id]; !ok || descriptions[id] == "" {
+			descriptions[id] = description
+		}
+	}
+
+	for _, name := range sortedKeys(config.Filter.Filters) {
+		addFilter(name, config.Filter.Filters[name].Description)
+	}
+	for _, id := range sortedKeys(header.Filter) {
+		filter := header.Filter[id]
+		if newFilter, ok := config.Filter.Map[id]; ok {
+			addFilter(newFilter, filter.Description)
+		} else {
+			addFilter(id, filter.Description)
+		}
+	}
+	for _, id := range sortedKeys(config.Filter.Map) {
+		addFilter(config.Filter.Map[id], "")
+	}
+
+	ids := sortedKeys(descriptions)
+	lines := []HeaderLineIdDescription{}
+	for _, id := range append([]string{"PASS"}, slices.DeleteFunc(ids, func(id string) bool { return id == "PASS" })...) {
+		lines = append(lines, HeaderLineIdDescription{
+			Id:          id,
+			Description: descriptions[id],
+		})
+	}
+	return lines
+}
+
+// Get the keys of a map in alphabetical order
+func sortedKeys[V any](input map[string]V) []string {
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
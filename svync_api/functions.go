@@ -2,32 +2,31 @@ package svync_api
 
 import (
 	"fmt"
-	"log"
-	"os"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
-func resolveFunction(input string, token string) string {
-	logger := log.New(os.Stderr, "", 0)
+func resolveFunction(input string, token string, config *Config) string {
+	logger := newLogger()
 
-	result := ""
-	prefixRegex := regexp.MustCompile(`^([^~]*)~`)
-	prefixResults := prefixRegex.FindStringSubmatch(input)
-	if len(prefixResults) > 0 {
-		result += prefixResults[1]
-	}
-	functionRegex := regexp.MustCompile(`~(\w+):([^;]*)`)
+	functionRegex := regexp.MustCompile(regexp.QuoteMeta(token) + `(\w+):([^;]*)`)
 	functionResults := functionRegex.FindStringSubmatch(input)
 	if len(functionResults) == 0 {
 		logger.Fatalf("No function found in '%s'", input)
 	}
+	// Keep the text before the function
+	result := input[:functionRegex.FindStringIndex(input)[0]]
 	function := functionResults[1]
 	value := strings.Split(functionResults[2], ",")
+	if function == "if" {
+		// The condition can contain commas (e.g. in regular expressions), only the last two commas separate the arguments
+		value = splitLast(functionResults[2], ",", 3)
+	}
 	for v := range value {
-		if strings.Contains(value[v], token) {
-			value[v] = resolveFunction(strings.Join(value[v:], ","), token)
+		if containsFunction(value[v], token) {
+			value[v] = resolveFunction(strings.Join(value[v:], ","), token, config)
 		}
 	}
 
@@ -38,6 +37,16 @@ func resolveFunction(input string, token string) string {
 		result += sum(value)
 	case "len":
 		result += fmt.Sprint(len(value[0]))
+	case "if":
+		result += ifElse(value)
+	case "refseq":
+		result += refseq(value, config)
+	case "gc":
+		result += gcContent(value, config)
+	case "homlen":
+		result += fmt.Sprint(len(homology(value, config)))
+	case "homseq":
+		result += homology(value, config)
 	default:
 		logger.Fatalf("The function '%s' is not supported", function)
 	}
@@ -60,10 +69,97 @@ func sum(input []string) string {
 	return floatToString(result)
 }
 
+func ifElse(input []string) string {
+	values := append(input, "", "")
+	if evaluateCondition(values[0]) {
+		return values[1]
+	}
+	return values[2]
+}
+
+// Check if the input contains a function call (e.g. ~len:), the token of a regex condition (=~) isn't a function
+func containsFunction(input string, token string) bool {
+	return regexp.MustCompile(regexp.QuoteMeta(token) + `\w+:`).MatchString(input)
+}
+
+// Split the input into at most n parts, splitting on the last separators
+func splitLast(input string, separator string, n int) []string {
+	parts := []string{}
+	for len(parts) < n-1 {
+		index := strings.LastIndex(input, separator)
+		if index < 0 {
+			break
+		}
+		parts = append([]string{input[index+len(separator):]}, parts...)
+		input = input[:index]
+	}
+	return append([]string{input}, parts...)
+}
+
+func refseq(input []string, config *Config) string {
+	contig, start, end := referenceRegion(input, config)
+	return referenceSequence(contig, start, end, config)
+}
+
+func gcContent(input []string, config *Config) string {
+	sequence := refseq(input, config)
+	gc := strings.Count(sequence, "G") + strings.Count(sequence, "C")
+	total := len(sequence) - strings.Count(sequence, "N")
+	if total == 0 {
+		return "."
+	}
+	return floatToString(math.Round(float64(gc)/float64(total)*10000) / 10000)
+}
+
+// Get the microhomology at the breakpoints of a deletion from <pos> to <end>
+// This is the sequence directly after POS that is identical to the sequence directly after END
+func homology(input []string, config *Config) string {
+	contig, pos, end := referenceRegion(input, config)
+	length := min(end-pos, 1000, config.reference.contigLength(contig)-end)
+	if length <= 0 {
+		return ""
+	}
+
+	deleted := referenceSequence(contig, pos+1, pos+length, config)
+	after := referenceSequence(contig, end+1, end+length, config)
+	homologyLength := 0
+	for homologyLength < len(deleted) && deleted[homologyLength] == after[homologyLength] && deleted[homologyLength] != 'N' {
+		homologyLength++
+	}
+	return deleted[:homologyLength]
+}
+
+// Parse the contig, start and end arguments of a function that uses the reference
+func referenceRegion(input []string, config *Config) (string, int64, int64) {
+	logger := newLogger()
+
+	if config.reference == nil {
+		logger.Fatalf("Functions that use the reference sequence need a reference FASTA file (--reference)")
+	}
+	if len(input) < 3 {
+		logger.Fatalf("Functions that use the reference sequence need three arguments: <chromosome>,<start>,<end>")
+	}
+
+	contig, ok := config.reference.contigName(input[0], config)
+	if !ok {
+		logger.Fatalf("The contig %s is not present in the reference", input[0])
+	}
+	return contig, int64(stringToFloat(input[1])), int64(stringToFloat(input[2]))
+}
+
+func referenceSequence(contig string, start int64, end int64, config *Config) string {
+	logger := newLogger()
+	sequence, err := config.reference.sequence(contig, start, end)
+	if err != nil {
+		logger.Fatalf("Failed to get the reference sequence: %v", err)
+	}
+	return sequence
+}
+
 func stringToFloat(input string) float64 {
 	result, err := strconv.ParseFloat(input, 64)
 	if err != nil {
-		log.Fatalf("Cannot convert '%s' to float64", input)
+		newLogger().Fatalf("Cannot convert '%s' to float64", input)
 	}
 	return result
 }
@@ -0,0 +1,50 @@
+package svync_api
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// A logger that writes warnings to stderr
+// Fatal errors panic with a fatalError instead of exiting, so a caller can recover from the error of a single file
+type svyncLogger struct {
+	*log.Logger
+}
+
+// An error that stops the processing of the current file
+type fatalError struct {
+	message string
+}
+
+func (err fatalError) Error() string {
+	return err.message
+}
+
+// Create a new logger that writes to stderr
+func newLogger() *svyncLogger {
+	return &svyncLogger{log.New(os.Stderr, "", 0)}
+}
+
+// Stop the processing of the current file with a formatted message
+func (logger *svyncLogger) Fatalf(format string, v ...any) {
+	panic(fatalError{fmt.Sprintf(format, v...)})
+}
+
+// Stop the processing of the current file with a message
+func (logger *svyncLogger) Fatal(v ...any) {
+	panic(fatalError{fmt.Sprint(v...)})
+}
+
+// Write the message of a fatal error to stderr and exit, this should be deferred in the main function
+// Other panics are passed on
+func ExitOnFatal() {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if err, ok := recovered.(fatalError); ok {
+		log.New(os.Stderr, "", 0).Fatal(err)
+	}
+	panic(recovered)
+}
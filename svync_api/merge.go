@@ -0,0 +1,216 @@
+package svync_api
+
+import (
+	"fmt"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// A struct representing the standardized variants of one caller (merge) or of one file (combine)
+type callset struct {
+	// The name of the caller or the file
+	Name string
+
+	// The header and the variants of the file
+	Header   *Header
+	Variants []*Variant
+
+	// The names of the FORMAT columns of the samples in the merged file
+	// The key is the sample in the file of the caller
+	columns map[string]string
+}
+
+// A struct representing a cluster of variants that describe the same event
+type variantCluster struct {
+	// The variants in the cluster, the key is the index of the callset
+	variants map[int]*Variant
+
+	// The variant of the first callset in the cluster, new variants are compared to this variant
+	representative *Variant
+}
+
+// Merge the standardized VCF files of multiple callers into a consensus callset
+func Merge(Cctx *cli.Context) {
+	logger := newLogger()
+
+	files := Cctx.Args().Slice()
+	if len(files) < 2 {
+		logger.Fatalf("The merge command needs at least two input files")
+	}
+	params := newMatchParameters(Cctx)
+	names := Cctx.StringSlice("names")
+	if len(names) == 0 {
+		for _, file := range files {
+			names = append(names, fileName(file))
+		}
+	}
+	if len(names) != len(files) {
+		logger.Fatalf("The number of names (%d) doesn't match the number of input files (%d)", len(names), len(files))
+	}
+
+	callsets := []*callset{}
+	headers := []*Header{}
+	for index, file := range files {
+		header, variants := readVariants(file, Cctx)
+		callsets = append(callsets, &callset{Name: names[index], Header: header, Variants: variants})
+		headers = append(headers, header)
+	}
+
+	header := unionHeaders(headers)
+	header.Info["SUPP"] = HeaderLineIdNumberTypeDescription{Id: "SUPP", Number: "1", Type: "Integer", Description: "Number of callers that support the variant"}
+	header.Info["SUPP_VEC"] = HeaderLineIdNumberTypeDescription{Id: "SUPP_VEC", Number: "1", Type: "String", Description: "Vector of the callers that support the variant, in the order of the callers"}
+	header.Info["CALLERS"] = HeaderLineIdNumberTypeDescription{Id: "CALLERS", Number: ".", Type: "String", Description: "Callers that support the variant"}
+	if _, ok := header.Format["GT"]; !ok {
+		header.Format["GT"] = HeaderLineIdNumberTypeDescription{Id: "GT", Number: "1", Type: "String", Description: "Genotype"}
+	}
+
+	// Every caller gets a FORMAT column for each of its samples
+	for _, caller := range callsets {
+		caller.columns = map[string]string{}
+		for _, sample := range caller.Header.Samples {
+			column := caller.Name
+			if len(caller.Header.Samples) > 1 {
+				column = fmt.Sprintf("%s_%s", caller.Name, sample)
+			}
+			caller.columns[sample] = column
+			header.Samples = append(header.Samples, column)
+		}
+	}
+
+	clusters := clusterCallsets(callsets, header, params)
+
+	config := headerConfig(header)
+	outputFile, stdout, closeOutput := createOutput(Cctx, config)
+	defer closeOutput()
+
+	writeHeader(config, Cctx, header, outputFile, stdout)
+	minSupport := Cctx.Int("min-support")
+	for _, cluster := range clusters {
+		if len(cluster.variants) < minSupport {
+			continue
+		}
+		writeVariant(config, Cctx, cluster.merge(callsets, header), outputFile, stdout)
+	}
+}
+
+// Cluster the variants of all callsets, a cluster contains at most one variant of each callset
+// The clusters are returned in the order of their first variant
+func clusterCallsets(callsets []*callset, header *Header, params *matchParameters) []*variantCluster {
+	variants := []*Variant{}
+	origin := map[*Variant]int{}
+	for index, caller := range callsets {
+		for _, variant := range caller.Variants {
+			variants = append(variants, variant)
+			origin[variant] = index
+		}
+	}
+	sortVariants(variants, header)
+
+	clusters := []*variantCluster{}
+	active := []*variantCluster{}
+	for _, variant := range variants {
+		index := origin[variant]
+
+		// Clusters of which the first variant is too far away can't get new variants
+		remaining := []*variantCluster{}
+		for _, cluster := range active {
+			if cluster.representative.Chromosome == variant.Chromosome && variant.Pos-cluster.representative.Pos <= params.Distance {
+				remaining = append(remaining, cluster)
+			}
+		}
+		active = remaining
+
+		var match *variantCluster
+		for _, cluster := range active {
+			if _, ok := cluster.variants[index]; ok {
+				continue
+			}
+			if params.match(cluster.representative, variant) {
+				match = cluster
+				break
+			}
+		}
+		if match == nil {
+			match = &variantCluster{variants: map[int]*Variant{}, representative: variant}
+			clusters = append(clusters, match)
+			active = append(active, match)
+		}
+		match.variants[index] = variant
+	}
+	return clusters
+}
+
+// Create the merged variant of a cluster with the INFO fields that describe the support of the callers
+func (cluster *variantCluster) merge(callsets []*callset, header *Header) *Variant {
+	merged := cluster.combine(callsets, header)
+
+	supportVector := ""
+	callers := []string{}
+	for index, caller := range callsets {
+		if _, ok := cluster.variants[index]; ok {
+			supportVector += "1"
+			callers = append(callers, caller.Name)
+		} else {
+			supportVector += "0"
+		}
+	}
+	merged.Info["SUPP"] = []string{fmt.Sprint(len(cluster.variants))}
+	merged.Info["SUPP_VEC"] = []string{supportVector}
+	merged.Info["CALLERS"] = []string{strings.Join(callers, ",")}
+	return merged
+}
+
+// Create the combined variant of a cluster
+// The ID, QUAL, FILTER and INFO values are taken from the variant of the first callset in the cluster and every callset gets its own FORMAT columns
+// INFO fields that are missing in the first variant are taken from the next variant that has them, the values of the other variants are dropped
+// The FORMAT columns of callsets that aren't in the cluster get a ./. genotype and missing values
+func (cluster *variantCluster) combine(callsets []*callset, header *Header) *Variant {
+	first := cluster.representative
+	for index := range callsets {
+		if variant, ok := cluster.variants[index]; ok {
+			first = variant
+			break
+		}
+	}
+
+	merged := newVariant()
+	merged.Header = header
+	merged.Chromosome = first.Chromosome
+	merged.Pos = first.Pos
+	merged.Id = first.Id
+	merged.Ref = first.Ref
+	merged.Alt = first.Alt
+	merged.Qual = first.Qual
+	merged.Filter = first.Filter
+	for index := range callsets {
+		variant, ok := cluster.variants[index]
+		if !ok {
+			continue
+		}
+		for key, value := range variant.Info {
+			if _, ok := merged.Info[key]; !ok {
+				merged.Info[key] = value
+			}
+		}
+	}
+
+	for index, caller := range callsets {
+		variant, ok := cluster.variants[index]
+		for _, sample := range caller.Header.Samples {
+			format := newVariantFormat()
+			format.Sample = caller.columns[sample]
+			for key := range header.Format {
+				format.Content[key] = []string{"."}
+			}
+			format.Content["GT"] = []string{"./."}
+			if ok {
+				for key, value := range variant.Format[sample].Content {
+					format.Content[key] = value
+				}
+			}
+			merged.Format[format.Sample] = *format
+		}
+	}
+	return merged
+}
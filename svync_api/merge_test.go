@@ -0,0 +1,45 @@
+package svync_api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterCombine(t *testing.T) {
+	header := newHeader()
+	header.Format["GT"] = HeaderLineIdNumberTypeDescription{Id: "GT", Number: "1", Type: "String", Description: "Genotype"}
+	header.Format["DP"] = HeaderLineIdNumberTypeDescription{Id: "DP", Number: "1", Type: "Integer", Description: "Depth"}
+
+	sample := func(name string, gt string) map[string]VariantFormat {
+		return map[string]VariantFormat{name: {Sample: name, Content: map[string][]string{"GT": {gt}, "DP": {"10"}}}}
+	}
+	callsets := []*callset{
+		{Name: "s1", Header: &Header{Samples: []string{"S1"}}, columns: map[string]string{"S1": "S1"}},
+		{Name: "s2", Header: &Header{Samples: []string{"S2"}}, columns: map[string]string{"S2": "S2"}},
+		{Name: "s3", Header: &Header{Samples: []string{"S3"}}, columns: map[string]string{"S3": "S3"}},
+	}
+	second := &Variant{Chromosome: "chr1", Pos: 100, Id: "del_s2", Alt: "<DEL>", Qual: "10", Filter: "PASS", Info: map[string][]string{"SVTYPE": {"DEL"}, "END": {"200"}}, Format: sample("S2", "0/1")}
+	third := &Variant{Chromosome: "chr1", Pos: 101, Id: "del_s3", Alt: "<DEL>", Qual: "30", Filter: "LowQual", Info: map[string][]string{"SVTYPE": {"DEL"}, "END": {"201"}, "CIPOS": {"-5", "5"}}, Format: sample("S3", "1/1")}
+	cluster := &variantCluster{variants: map[int]*Variant{1: second, 2: third}, representative: second}
+
+	combined := cluster.combine(callsets, header)
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"ID of the first file", combined.Id, "del_s2"},
+		{"QUAL of the first file", combined.Qual, "10"},
+		{"FILTER of the first file", combined.Filter, "PASS"},
+		{"INFO of the first file", combined.Info["END"], []string{"200"}},
+		{"missing INFO of another file", combined.Info["CIPOS"], []string{"-5", "5"}},
+		{"sample without the variant", combined.Format["S1"].Content, map[string][]string{"GT": {"./."}, "DP": {"."}}},
+		{"sample with the variant", combined.Format["S3"].Content["GT"], []string{"1/1"}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
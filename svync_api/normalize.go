@@ -0,0 +1,226 @@
+package svync_api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// The maximum amount of bases a breakpoint can be shifted during the normalization
+const maxNormalizationShift = 1000
+
+// Shift the breakpoints of a standardized deletion, duplication or insertion to the leftmost or rightmost
+// position within its microhomology or repeat. POS, END, CIPOS, CIEND and the REF/ALT bases are updated.
+func (config *Config) normalizeBreakpoints(variant *Variant, direction string, Cctx *cli.Context) {
+	logger := newLogger()
+
+	keys := variant.altKeys()
+	if len(keys) == 0 {
+		return
+	}
+	contig, ok := config.reference.contigName(variant.Chromosome, config)
+	if !ok {
+		return
+	}
+	literal := isLiteralAllele(variant.Ref) && isLiteralAllele(variant.Alt)
+
+	var err error
+	switch keys[len(keys)-1] {
+	case "DEL", "DUP":
+		if literal && (keys[len(keys)-1] == "DUP" || len(variant.Alt) != 1) {
+			return
+		}
+		err = config.normalizeSegment(variant, contig, direction, literal)
+	case "INS":
+		if literal && len(variant.Ref) != 1 {
+			return
+		}
+		err = config.normalizeInsertion(variant, contig, direction, literal)
+	}
+	if err != nil && !Cctx.Bool("mute-warnings") {
+		logger.Printf("Failed to normalize the breakpoints of the variant with ID %s: %v", variant.Id, err)
+	}
+}
+
+// Normalize a deletion or duplication of the segment after POS up to END
+func (config *Config) normalizeSegment(variant *Variant, contig string, direction string, literal bool) error {
+	pos := variant.Pos
+	end := pos + int64(len(variant.Ref)) - 1
+	if !literal {
+		var err error
+		if end, err = infoInt(variant, "END"); err != nil {
+			return err
+		}
+	}
+	if end <= pos {
+		return nil
+	}
+	contigLength := config.reference.contigLength(contig)
+
+	// The segment can be shifted left as long as the base at POS equals the base at END
+	leftStart := max(1, pos-maxNormalizationShift+1)
+	left := []byte{}
+	leftEnd := []byte{}
+	if leftStart <= pos {
+		bases, err := config.reference.sequence(contig, leftStart, pos)
+		if err != nil {
+			return err
+		}
+		left = []byte(bases)
+		bases, err = config.reference.sequence(contig, end-(pos-leftStart), end)
+		if err != nil {
+			return err
+		}
+		leftEnd = []byte(bases)
+	}
+	leftShift := int64(0)
+	for leftShift < int64(len(left)) && left[len(left)-1-int(leftShift)] == leftEnd[len(leftEnd)-1-int(leftShift)] && pos-leftShift > 1 {
+		leftShift++
+	}
+
+	// The segment can be shifted right as long as the base after POS equals the base after END
+	rightLength := min(maxNormalizationShift, contigLength-end)
+	rightShift := int64(0)
+	if rightLength > 0 {
+		right, err := config.reference.sequence(contig, pos+1, pos+rightLength)
+		if err != nil {
+			return err
+		}
+		rightEnd, err := config.reference.sequence(contig, end+1, end+rightLength)
+		if err != nil {
+			return err
+		}
+		for rightShift < rightLength && right[rightShift] == rightEnd[rightShift] {
+			rightShift++
+		}
+	}
+
+	shift := -leftShift
+	if direction == "right" {
+		shift = rightShift
+	}
+	variant.shiftBreakpoints(shift, leftShift+rightShift, direction)
+	if shift == 0 {
+		return nil
+	}
+
+	if literal {
+		bases, err := config.reference.sequence(contig, variant.Pos, end+shift)
+		if err != nil {
+			return err
+		}
+		variant.Ref = bases
+		variant.Alt = bases[:1]
+		return nil
+	}
+	return config.updateAnchorBase(variant, contig)
+}
+
+// Normalize an insertion with a known inserted sequence (literal ALT or SVINSSEQ)
+func (config *Config) normalizeInsertion(variant *Variant, contig string, direction string, literal bool) error {
+	insertion := ""
+	if literal {
+		insertion = strings.ToUpper(variant.Alt[1:])
+	} else if sequence, ok := variant.Info["SVINSSEQ"]; ok && len(sequence) == 1 && isLiteralAllele(sequence[0]) {
+		insertion = strings.ToUpper(sequence[0])
+	}
+	if insertion == "" {
+		return nil
+	}
+	pos := variant.Pos
+	contigLength := config.reference.contigLength(contig)
+
+	// The insertion can be shifted left as long as the base at POS equals the last inserted base
+	leftStart := max(1, pos-maxNormalizationShift+1)
+	left, err := config.reference.sequence(contig, leftStart, pos)
+	if err != nil {
+		return err
+	}
+	leftInsertion := insertion
+	leftShift := int64(0)
+	for leftShift < int64(len(left))-1 && left[len(left)-1-int(leftShift)] == leftInsertion[len(leftInsertion)-1] {
+		leftInsertion = string(left[len(left)-1-int(leftShift)]) + leftInsertion[:len(leftInsertion)-1]
+		leftShift++
+	}
+
+	// The insertion can be shifted right as long as the base after POS equals the first inserted base
+	rightLength := min(maxNormalizationShift, contigLength-pos)
+	rightInsertion := insertion
+	rightShift := int64(0)
+	if rightLength > 0 {
+		right, err := config.reference.sequence(contig, pos+1, pos+rightLength)
+		if err != nil {
+			return err
+		}
+		for rightShift < rightLength && right[rightShift] == rightInsertion[0] {
+			rightInsertion = rightInsertion[1:] + string(right[rightShift])
+			rightShift++
+		}
+	}
+
+	shift := -leftShift
+	insertion = leftInsertion
+	if direction == "right" {
+		shift = rightShift
+		insertion = rightInsertion
+	}
+	variant.shiftBreakpoints(shift, leftShift+rightShift, direction)
+	if shift == 0 {
+		return nil
+	}
+
+	if _, ok := variant.Info["SVINSSEQ"]; ok {
+		variant.Info["SVINSSEQ"] = []string{insertion}
+	}
+	if err := config.updateAnchorBase(variant, contig); err != nil {
+		return err
+	}
+	if literal {
+		variant.Alt = variant.Ref + insertion
+	}
+	return nil
+}
+
+// Shift POS and END of a variant and update the confidence intervals to cover the homology
+func (variant *Variant) shiftBreakpoints(shift int64, homologyLength int64, direction string) {
+	variant.Pos += shift
+	if end, err := infoInt(variant, "END"); err == nil {
+		variant.Info["END"] = []string{fmt.Sprint(end + shift)}
+	}
+
+	// The breakpoints can be anywhere within the homology
+	homologyStart, homologyEnd := -homologyLength, int64(0)
+	if direction == "left" {
+		homologyStart, homologyEnd = 0, homologyLength
+	}
+	for _, field := range []string{"CIPOS", "CIEND"} {
+		interval, ok := variant.Info[field]
+		if !ok {
+			continue
+		}
+		if len(interval) == 1 {
+			interval = strings.Split(interval[0], ",")
+		}
+		if len(interval) != 2 {
+			continue
+		}
+		start, errStart := strconv.ParseInt(interval[0], 10, 64)
+		end, errEnd := strconv.ParseInt(interval[1], 10, 64)
+		if errStart != nil || errEnd != nil {
+			continue
+		}
+		variant.Info[field] = []string{fmt.Sprintf("%d,%d", min(start-shift, homologyStart), max(end-shift, homologyEnd))}
+	}
+}
+
+// Set the REF of a variant to the reference base at POS
+func (config *Config) updateAnchorBase(variant *Variant, contig string) error {
+	base, err := config.reference.sequence(contig, variant.Pos, variant.Pos)
+	if err != nil {
+		return err
+	}
+	variant.Ref = base
+	return nil
+}
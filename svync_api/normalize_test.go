@@ -0,0 +1,114 @@
+package svync_api
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// Write the sequences to a FASTA file and load it as the reference
+func testReference(t *testing.T, sequences map[string]string) *Reference {
+	t.Helper()
+
+	fasta := ""
+	for _, name := range sortedKeys(sequences) {
+		fasta += ">" + name + "\n" + sequences[name] + "\n"
+	}
+	path := filepath.Join(t.TempDir(), "reference.fa")
+	if err := os.WriteFile(path, []byte(fasta), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("reference", path, "")
+	flags.Bool("mute-warnings", true, "")
+	reference := loadReference(cli.NewContext(cli.NewApp(), flags, nil))
+	t.Cleanup(reference.close)
+	return reference
+}
+
+func TestNormalizeBreakpoints(t *testing.T) {
+	Cctx := testContext()
+	// The CA repeat spans position 5 to 10
+	config := &Config{reference: testReference(t, map[string]string{"chr1": "TTTGCACACAGTTT"})}
+
+	tests := []struct {
+		name      string
+		direction string
+		variant   *Variant
+		pos       int64
+		ref       string
+		alt       string
+		info      map[string][]string
+	}{
+		{
+			"literal deletion to the left", "left",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "ACA", Alt: "A", Info: map[string][]string{"SVTYPE": {"DEL"}}},
+			4, "GCA", "G", map[string][]string{"SVTYPE": {"DEL"}},
+		},
+		{
+			"literal deletion to the right", "right",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "ACA", Alt: "A", Info: map[string][]string{"SVTYPE": {"DEL"}}},
+			8, "ACA", "A", map[string][]string{"SVTYPE": {"DEL"}},
+		},
+		{
+			"symbolic deletion to the left", "left",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "A", Alt: "<DEL>", Info: map[string][]string{"SVTYPE": {"DEL"}, "END": {"8"}, "CIPOS": {"0", "0"}}},
+			4, "G", "<DEL>", map[string][]string{"SVTYPE": {"DEL"}, "END": {"6"}, "CIPOS": {"0,4"}},
+		},
+		{
+			"symbolic deletion to the right", "right",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "A", Alt: "<DEL>", Info: map[string][]string{"SVTYPE": {"DEL"}, "END": {"8"}, "CIPOS": {"0", "0"}}},
+			8, "A", "<DEL>", map[string][]string{"SVTYPE": {"DEL"}, "END": {"10"}, "CIPOS": {"-4,0"}},
+		},
+		{
+			"literal insertion to the left", "left",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "A", Alt: "ACA", Info: map[string][]string{"SVTYPE": {"INS"}}},
+			4, "G", "GCA", map[string][]string{"SVTYPE": {"INS"}},
+		},
+		{
+			"literal insertion to the right", "right",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "A", Alt: "ACA", Info: map[string][]string{"SVTYPE": {"INS"}}},
+			10, "A", "ACA", map[string][]string{"SVTYPE": {"INS"}},
+		},
+		{
+			"symbolic insertion with a sequence", "right",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "A", Alt: "<INS>", Info: map[string][]string{"SVTYPE": {"INS"}, "SVINSSEQ": {"CA"}}},
+			10, "A", "<INS>", map[string][]string{"SVTYPE": {"INS"}, "SVINSSEQ": {"CA"}},
+		},
+		{
+			"symbolic insertion without a sequence", "left",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "A", Alt: "<INS>", Info: map[string][]string{"SVTYPE": {"INS"}}},
+			6, "A", "<INS>", map[string][]string{"SVTYPE": {"INS"}},
+		},
+		{
+			"deletion without homology", "left",
+			&Variant{Chromosome: "chr1", Pos: 11, Ref: "GT", Alt: "G", Info: map[string][]string{"SVTYPE": {"DEL"}}},
+			11, "GT", "G", map[string][]string{"SVTYPE": {"DEL"}},
+		},
+		{
+			"breakend", "left",
+			&Variant{Chromosome: "chr1", Pos: 6, Ref: "A", Alt: "A[chr1:10[", Info: map[string][]string{"SVTYPE": {"BND"}}},
+			6, "A", "A[chr1:10[", map[string][]string{"SVTYPE": {"BND"}},
+		},
+		{
+			"contig missing from the reference", "left",
+			&Variant{Chromosome: "chr2", Pos: 6, Ref: "ACA", Alt: "A", Info: map[string][]string{"SVTYPE": {"DEL"}}},
+			6, "ACA", "A", map[string][]string{"SVTYPE": {"DEL"}},
+		},
+	}
+	for _, test := range tests {
+		variant := test.variant
+		config.normalizeBreakpoints(variant, test.direction, Cctx)
+		if variant.Pos != test.pos || variant.Ref != test.ref || variant.Alt != test.alt {
+			t.Errorf("%s: got %d %s %s, want %d %s %s", test.name, variant.Pos, variant.Ref, variant.Alt, test.pos, test.ref, test.alt)
+		}
+		if !reflect.DeepEqual(variant.Info, test.info) {
+			t.Errorf("%s: INFO = %v, want %v", test.name, variant.Info, test.info)
+		}
+	}
+}
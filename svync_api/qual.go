@@ -0,0 +1,58 @@
+package svync_api
+
+import (
+	"math"
+	"strconv"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// Resolve the QUAL value of a variant using the config, without rescaling it
+func (config *Config) resolveQual(variant *Variant, Cctx *cli.Context) string {
+	if value, ok := config.Qual.Alts.get(variant.altKeys()); ok {
+		return ResolveValue(value, variant, nil, Cctx, config)
+	} else if config.Qual.Value != "" {
+		return ResolveValue(config.Qual.Value, variant, nil, Cctx, config)
+	}
+	return variant.Qual
+}
+
+// Standardize the QUAL value of a variant using the config
+func (config *Config) standardizeQual(variant *Variant, Cctx *cli.Context) string {
+	qual := config.resolveQual(variant, Cctx)
+	if config.Qual.Rescale.Method == "" {
+		return qual
+	}
+
+	value, err := strconv.ParseFloat(qual, 64)
+	if err != nil {
+		// Missing or non-numeric values can't be rescaled
+		return qual
+	}
+
+	rescale := config.Qual.Rescale
+	switch rescale.Method {
+	case "percentile":
+		value = variant.Header.qualStatistics.percentileRank(value)
+	case "logistic":
+		value = rescale.Scale / (1 + math.Exp(-rescale.Steepness*(value-rescale.Midpoint)))
+	}
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// Validate the rescale configuration of the QUAL field
+func (rescale *ConfigRescale) validate() {
+	logger := newLogger()
+	switch rescale.Method {
+	case "", "percentile":
+	case "logistic":
+		if rescale.Steepness == 0 {
+			logger.Fatalf("The logistic rescaling of the QUAL field needs a non-zero 'steepness'")
+		}
+		if rescale.Scale == 0 {
+			rescale.Scale = 100
+		}
+	default:
+		logger.Fatalf("The QUAL rescaling method '%s' is not supported, use 'percentile' or 'logistic'", rescale.Method)
+	}
+}
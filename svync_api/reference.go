@@ -0,0 +1,174 @@
+package svync_api
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/biogo/hts/fai"
+	cli "github.com/urfave/cli/v2"
+)
+
+// A struct representing an indexed reference FASTA file
+type Reference struct {
+	// The path to the FASTA file
+	Path string
+
+	// The FASTA file with its index
+	fasta *fai.File
+
+	// The opened FASTA file
+	file *os.File
+}
+
+// Open the reference FASTA file given with --reference
+// The index is read from the .fai file next to the FASTA file or created when it doesn't exist
+func loadReference(Cctx *cli.Context) *Reference {
+	logger := newLogger()
+
+	path := Cctx.String("reference")
+	if path == "" {
+		return nil
+	}
+
+	fastaFile, err := os.Open(path)
+	if err != nil {
+		logger.Fatalf("Failed to open the reference FASTA file: %v", err)
+	}
+
+	var index fai.Index
+	if indexFile, err := os.Open(path + ".fai"); err == nil {
+		index, err = fai.ReadFrom(indexFile)
+		indexFile.Close()
+		if err != nil {
+			logger.Fatalf("Failed to read the index of the reference FASTA file: %v", err)
+		}
+	} else {
+		if !Cctx.Bool("mute-warnings") {
+			logger.Printf("No index found for the reference FASTA file at %s.fai, creating it in memory", path)
+		}
+		index, err = fai.NewIndex(fastaFile)
+		if err != nil {
+			logger.Fatalf("Failed to index the reference FASTA file: %v", err)
+		}
+	}
+
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		absolutePath = path
+	}
+
+	return &Reference{
+		Path:  absolutePath,
+		fasta: fai.NewFile(fastaFile, index),
+		file:  fastaFile,
+	}
+}
+
+// Close the reference FASTA file
+func (reference *Reference) close() {
+	reference.file.Close()
+}
+
+// Get the name of the chromosome as it is used in the reference
+// The original name is tried first, followed by the renamed chromosome
+func (reference *Reference) contigName(chromosome string, config *Config) (string, bool) {
+	if _, ok := reference.fasta.Index[chromosome]; ok {
+		return chromosome, true
+	}
+	renamed := config.renameChromosome(chromosome)
+	if _, ok := reference.fasta.Index[renamed]; ok {
+		return renamed, true
+	}
+	return chromosome, false
+}
+
+// Get the length of a contig in the reference, returns 0 when the contig is not present
+func (reference *Reference) contigLength(contig string) int64 {
+	return int64(reference.fasta.Index[contig].Length)
+}
+
+// Get the uppercase reference sequence from start to end (1-based and inclusive)
+func (reference *Reference) sequence(contig string, start int64, end int64) (string, error) {
+	record, ok := reference.fasta.Index[contig]
+	if !ok {
+		return "", fmt.Errorf("the contig %s is not present in the reference", contig)
+	}
+	if start < 1 || end < start || end > int64(record.Length) {
+		return "", fmt.Errorf("the region %s:%d-%d is outside of the reference", contig, start, end)
+	}
+
+	seq, err := reference.fasta.SeqRange(contig, int(start-1), int(end))
+	if err != nil {
+		return "", err
+	}
+	bases, err := io.ReadAll(seq)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(string(bases)), nil
+}
+
+// Get the contigs of the reference in the order of the FASTA file
+func (reference *Reference) contigs() []HeaderLineContig {
+	records := []fai.Record{}
+	for _, record := range reference.fasta.Index {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Start < records[j].Start })
+
+	contigs := []HeaderLineContig{}
+	for _, record := range records {
+		contigs = append(contigs, HeaderLineContig{
+			Id:     record.Name,
+			Length: int64(record.Length),
+		})
+	}
+	return contigs
+}
+
+// Standardize the REF field of a variant using the reference
+// Placeholder bases (N) are replaced with the reference bases and mismatches are reported
+func (config *Config) standardizeRef(variant *Variant, Cctx *cli.Context) string {
+	logger := newLogger()
+
+	if config.reference == nil {
+		return variant.Ref
+	}
+
+	reportError := func(message string) {
+		if Cctx.Bool("strict-reference") {
+			logger.Fatalf("%s (variant with ID %s)", message, variant.Id)
+		} else if !Cctx.Bool("mute-warnings") {
+			logger.Printf("%s (variant with ID %s)", message, variant.Id)
+		}
+	}
+
+	contig, ok := config.reference.contigName(variant.Chromosome, config)
+	if !ok {
+		reportError(fmt.Sprintf("The contig %s is not present in the reference", variant.Chromosome))
+		return variant.Ref
+	}
+
+	ref := strings.ToUpper(variant.Ref)
+	length := int64(len(ref))
+	if ref == "" || ref == "." {
+		length = 1
+	}
+	bases, err := config.reference.sequence(contig, variant.Pos, variant.Pos+length-1)
+	if err != nil {
+		reportError(fmt.Sprintf("Failed to get the reference bases: %v", err))
+		return variant.Ref
+	}
+
+	if strings.Trim(ref, "N") == "" || ref == "." {
+		return bases
+	}
+	if ref != bases {
+		reportError(fmt.Sprintf("The REF %s at %s:%d does not match the reference (%s)", variant.Ref, variant.Chromosome, variant.Pos, bases))
+	}
+	return variant.Ref
+}
@@ -2,8 +2,6 @@ package svync_api
 
 import (
 	"fmt"
-	"log"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,7 +11,17 @@ import (
 
 // Resolve a value
 func ResolveValue(input string, variant *Variant, format *VariantFormat, Cctx *cli.Context, config *Config) string {
-	logger := log.New(os.Stderr, "", 0)
+	logger := newLogger()
+
+	// Replace all the file statistics
+	input = statisticsRegex.ReplaceAllStringFunc(input, func(rawField string) string {
+		match := statisticsRegex.FindStringSubmatch(rawField)
+		stats, ok := variant.Header.statistics[match[1]]
+		if !ok {
+			logger.Fatalf("No statistics were gathered for %s, please run svync in the two-pass mode (--two-pass)", match[1])
+		}
+		return stats.get(match[2])
+	})
 
 	// Replace all the FORMAT fields
 	formatRegex := regexp.MustCompile(`\$FORMAT/[\w\d]+(/\d+)?`)
@@ -63,6 +71,9 @@ func ResolveValue(input string, variant *Variant, format *VariantFormat, Cctx *c
 			} else if infoType != "Flag" && !Cctx.Bool("mute-warnings") {
 				logger.Printf("The field %s is not present in the FORMAT fields of the variant with ID %s, excluding it from this variant. Supply a default to mute this warning", field, variant.Id)
 			}
+		} else if len(info) == 0 || variant.Header.Info[field].Type == "Flag" {
+			// Flags that are present on the variant resolve to true
+			info = []string{"true"}
 		} else if len(fieldSlice) > 2 {
 			index, err := strconv.ParseInt(fieldSlice[2], 0, 64)
 			if err != nil {
@@ -92,8 +103,8 @@ func ResolveValue(input string, variant *Variant, format *VariantFormat, Cctx *c
 	input = strings.ReplaceAll(input, "$FILTER", variant.Filter)
 
 	functionToken := "~"
-	if !strings.Contains(input, functionToken) {
+	if !containsFunction(input, functionToken) {
 		return input
 	}
-	return resolveFunction(input, functionToken)
+	return resolveFunction(input, functionToken, config)
 }
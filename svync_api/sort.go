@@ -0,0 +1,257 @@
+package svync_api
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"io"
+	"os"
+	"sort"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// A struct that sorts the standardized variants before they are written
+// When there are more variants than fit in the buffer, the sorted buffer is spilled to a temporary file
+// and the temporary files are merged at the end
+type variantSorter struct {
+	// The maximum number of variants kept in memory
+	bufferSize int
+
+	// The variants in memory
+	buffer []*Variant
+
+	// The header of the variants, the order of its contigs is used to sort the variants
+	header *Header
+
+	// The index of every contig (after renaming) in the output header
+	order map[string]int
+
+	// The directory of the temporary files and the temporary files with the sorted chunks
+	directory string
+	chunks    []string
+}
+
+// A struct representing a variant in a temporary file
+type sortRecord struct {
+	Chromosome string
+	Pos        int64
+	Id         string
+	Ref        string
+	Alt        string
+	Qual       string
+	Filter     string
+	Info       map[string][]string
+	Format     map[string]VariantFormat
+}
+
+// A struct representing a sorted chunk that is being merged
+type sortChunk struct {
+	// The index of the chunk, variants at the same position are written in the order of the chunks
+	index int
+
+	// The next variant of the chunk
+	variant *Variant
+
+	// The decoder of the temporary file and the temporary file itself
+	decoder *gob.Decoder
+	file    *os.File
+}
+
+// A heap of the sorted chunks, ordered by the next variant of every chunk
+type sortChunkHeap struct {
+	chunks []*sortChunk
+	order  map[string]int
+}
+
+func (chunks *sortChunkHeap) Len() int {
+	return len(chunks.chunks)
+}
+
+func (chunks *sortChunkHeap) Less(i, j int) bool {
+	comparison := compareVariants(chunks.chunks[i].variant, chunks.chunks[j].variant, chunks.order)
+	if comparison == 0 {
+		return chunks.chunks[i].index < chunks.chunks[j].index
+	}
+	return comparison < 0
+}
+
+func (chunks *sortChunkHeap) Swap(i, j int) {
+	chunks.chunks[i], chunks.chunks[j] = chunks.chunks[j], chunks.chunks[i]
+}
+
+func (chunks *sortChunkHeap) Push(chunk any) {
+	chunks.chunks = append(chunks.chunks, chunk.(*sortChunk))
+}
+
+func (chunks *sortChunkHeap) Pop() any {
+	last := chunks.chunks[len(chunks.chunks)-1]
+	chunks.chunks = chunks.chunks[:len(chunks.chunks)-1]
+	return last
+}
+
+// Create a sorter that keeps at most bufferSize variants in memory
+func newVariantSorter(bufferSize int) *variantSorter {
+	logger := newLogger()
+	if bufferSize <= 0 {
+		logger.Fatalf("The sort buffer size (--sort-buffer) should be at least 1")
+	}
+	return &variantSorter{bufferSize: bufferSize}
+}
+
+// Write the variant, or keep it until all variants have been read when the output is sorted
+func outputVariant(config *Config, Cctx *cli.Context, variant *Variant, file *os.File, stdout bool) {
+	if config.sorter != nil {
+		config.sorter.add(config, variant)
+		return
+	}
+	writeVariant(config, Cctx, variant, file, stdout)
+}
+
+// Add a variant to the sorter, the buffer is spilled to a temporary file when it's full
+func (sorter *variantSorter) add(config *Config, variant *Variant) {
+	if sorter.order == nil {
+		// The header is complete once the first variant has been standardized
+		sorter.header = variant.Header
+		sorter.order = map[string]int{}
+		for index, contig := range sorter.header.Contig {
+			sorter.order[config.renameChromosome(contig.Id)] = index
+		}
+	}
+	sorter.buffer = append(sorter.buffer, variant)
+	if len(sorter.buffer) >= sorter.bufferSize {
+		sorter.spill()
+	}
+}
+
+// Sort the buffer and write it to a new temporary file
+func (sorter *variantSorter) spill() {
+	logger := newLogger()
+
+	if sorter.directory == "" {
+		directory, err := os.MkdirTemp("", "svync-sort-")
+		if err != nil {
+			logger.Fatalf("Failed to create a temporary directory for sorting: %v", err)
+		}
+		sorter.directory = directory
+	}
+	file, err := os.CreateTemp(sorter.directory, "chunk-*.gob")
+	if err != nil {
+		logger.Fatalf("Failed to create a temporary file for sorting: %v", err)
+	}
+	defer file.Close()
+
+	sorter.sortBuffer()
+	writer := bufio.NewWriter(file)
+	encoder := gob.NewEncoder(writer)
+	for _, variant := range sorter.buffer {
+		record := sortRecord{
+			Chromosome: variant.Chromosome,
+			Pos:        variant.Pos,
+			Id:         variant.Id,
+			Ref:        variant.Ref,
+			Alt:        variant.Alt,
+			Qual:       variant.Qual,
+			Filter:     variant.Filter,
+			Info:       variant.Info,
+			Format:     variant.Format,
+		}
+		if err := encoder.Encode(&record); err != nil {
+			logger.Fatalf("Failed to write a temporary file for sorting: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		logger.Fatalf("Failed to write a temporary file for sorting: %v", err)
+	}
+	sorter.chunks = append(sorter.chunks, file.Name())
+	sorter.buffer = nil
+}
+
+// Sort the variants in memory, variants at the same position keep their order
+func (sorter *variantSorter) sortBuffer() {
+	sort.SliceStable(sorter.buffer, func(i, j int) bool {
+		return compareVariants(sorter.buffer[i], sorter.buffer[j], sorter.order) < 0
+	})
+}
+
+// Write all variants in sorted order and remove the temporary files
+func (sorter *variantSorter) flush(config *Config, Cctx *cli.Context, file *os.File, stdout bool) {
+	logger := newLogger()
+
+	if len(sorter.chunks) == 0 {
+		sorter.sortBuffer()
+		for _, variant := range sorter.buffer {
+			writeVariant(config, Cctx, variant, file, stdout)
+		}
+		sorter.buffer = nil
+		return
+	}
+	defer sorter.cleanup()
+	if len(sorter.buffer) > 0 {
+		sorter.spill()
+	}
+
+	chunks := &sortChunkHeap{order: sorter.order}
+	for index, name := range sorter.chunks {
+		chunkFile, err := os.Open(name)
+		if err != nil {
+			logger.Fatalf("Failed to open a temporary file for sorting: %v", err)
+		}
+		chunk := &sortChunk{index: index, decoder: gob.NewDecoder(bufio.NewReader(chunkFile)), file: chunkFile}
+		if sorter.next(chunk) {
+			chunks.chunks = append(chunks.chunks, chunk)
+		}
+	}
+	heap.Init(chunks)
+
+	for chunks.Len() > 0 {
+		chunk := chunks.chunks[0]
+		writeVariant(config, Cctx, chunk.variant, file, stdout)
+		if sorter.next(chunk) {
+			heap.Fix(chunks, 0)
+		} else {
+			heap.Pop(chunks)
+		}
+	}
+}
+
+// Remove the temporary files of the sorter
+func (sorter *variantSorter) cleanup() {
+	if sorter.directory != "" {
+		os.RemoveAll(sorter.directory)
+		sorter.directory = ""
+	}
+}
+
+// Read the next variant of a chunk, returns false and closes the chunk when it has no variants left
+func (sorter *variantSorter) next(chunk *sortChunk) bool {
+	logger := newLogger()
+
+	record := sortRecord{}
+	if err := chunk.decoder.Decode(&record); err != nil {
+		if err != io.EOF {
+			logger.Fatalf("Failed to read a temporary file for sorting: %v", err)
+		}
+		chunk.file.Close()
+		return false
+	}
+	chunk.variant = &Variant{
+		Chromosome: record.Chromosome,
+		Pos:        record.Pos,
+		Id:         record.Id,
+		Ref:        record.Ref,
+		Alt:        record.Alt,
+		Qual:       record.Qual,
+		Filter:     record.Filter,
+		Header:     sorter.header,
+		Info:       record.Info,
+		Format:     record.Format,
+	}
+	if chunk.variant.Info == nil {
+		chunk.variant.Info = map[string][]string{}
+	}
+	if chunk.variant.Format == nil {
+		chunk.variant.Format = map[string]VariantFormat{}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package svync_api
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVariantSorter(t *testing.T) {
+	Cctx := testContext()
+	header := newHeader()
+	for _, line := range []string{
+		`##fileformat=VCFv4.2`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+		`##contig=<ID=chr2,length=1000>`,
+		`##contig=<ID=chr1,length=1000>`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO",
+	} {
+		header.parse(line)
+	}
+	lines := []string{
+		"chr1\t500\tc\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL",
+		"chrUn10\t5\tg\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL",
+		"chr2\t300\tb\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL",
+		"chrUn2\t5\tf\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL",
+		"chr1\t100\td1\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL",
+		"chr2\t10\ta\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL",
+		// Variants at the same position keep the order of the input
+		"chr1\t100\td2\tN\t<DUP>\t.\tPASS\tSVTYPE=DUP",
+		"chr1\t100\td3\tN\t<INS>\t.\tPASS\tSVTYPE=INS",
+	}
+	// Contigs of the header first in the order of the header, then the other contigs in natural order
+	want := []string{"a", "b", "d1", "d2", "d3", "c", "f", "g"}
+
+	// The smaller buffers spill the variants to temporary files that are merged
+	for _, bufferSize := range []int{1, 2, 3, 100} {
+		config := &Config{Info: MapConfigInput{"SVTYPE": {Number: "1", Type: "String"}}, sorter: newVariantSorter(bufferSize)}
+		for _, line := range lines {
+			outputVariant(config, Cctx, createVariant(line, header, Cctx), nil, false)
+		}
+
+		path := filepath.Join(t.TempDir(), "sorted.vcf")
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		directory := config.sorter.directory
+		config.sorter.flush(config, Cctx, file, false)
+		file.Close()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			ids = append(ids, strings.Split(line, "\t")[2])
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("buffer size %d: got %v, want %v", bufferSize, ids, want)
+		}
+		if directory != "" {
+			if _, err := os.Stat(directory); !os.IsNotExist(err) {
+				t.Errorf("buffer size %d: the temporary directory %s wasn't removed", bufferSize, directory)
+			}
+		}
+	}
+}
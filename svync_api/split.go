@@ -0,0 +1,185 @@
+package svync_api
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// The characters that are replaced in the names of the split output files
+var unsafeFileNameRegex = regexp.MustCompile(`[^A-Za-z0-9._+-]`)
+
+// A struct that writes the variants to a separate output file for every SVTYPE, chromosome or sample
+type outputSplitter struct {
+	// How to split the output, can be "svtype", "chrom" or "sample"
+	mode string
+
+	// The path of the output files with a {svtype}, {chrom} or {sample} placeholder
+	template string
+
+	// The output format of all output files
+	format string
+
+	// The config and the header used to create the output files
+	config *Config
+	header *Header
+
+	// The output files, the key is the SVTYPE, chromosome or sample
+	outputs map[string]*splitOutput
+
+	// The keys of the output files in the order they were created
+	keys []string
+
+	// The key of every output path, used to detect keys that get the same file name
+	paths map[string]string
+}
+
+// A struct representing one of the split output files
+type splitOutput struct {
+	// A copy of the config with the BCF writer of this output file
+	config *Config
+
+	// The header of this output file
+	header *Header
+
+	// The path of the output file, the output file and a function that closes it
+	path  string
+	file  *os.File
+	close func()
+}
+
+// Create a splitter from the --split-by and --output arguments
+func newOutputSplitter(Cctx *cli.Context) *outputSplitter {
+	logger := newLogger()
+
+	mode := strings.ToLower(Cctx.String("split-by"))
+	switch mode {
+	case "svtype", "chrom", "sample":
+	default:
+		logger.Fatalf("The split mode '%s' is not supported, use 'svtype', 'chrom' or 'sample'", Cctx.String("split-by"))
+	}
+
+	template := Cctx.String("output")
+	placeholder := fmt.Sprintf("{%s}", mode)
+	if !strings.Contains(template, placeholder) {
+		logger.Fatalf("Splitting the output by %s (--split-by) needs an output path with a %s placeholder (e.g. out.%s.vcf.gz)", mode, placeholder, placeholder)
+	}
+
+	return &outputSplitter{
+		mode:     mode,
+		template: template,
+		format:   outputFormat(Cctx),
+		outputs:  map[string]*splitOutput{},
+		paths:    map[string]string{},
+	}
+}
+
+// Keep the header for the output files, in sample mode an output file is created for every sample
+func (splitter *outputSplitter) writeHeader(config *Config, Cctx *cli.Context, header *Header) {
+	logger := newLogger()
+
+	splitter.config = config
+	splitter.header = header
+	if splitter.mode != "sample" {
+		return
+	}
+	if len(header.Samples) == 0 {
+		logger.Fatalf("The output can't be split by sample, the input file has no samples")
+	}
+	for _, sample := range header.Samples {
+		splitter.output(sample, Cctx)
+	}
+}
+
+// Write the variant to the output file of its SVTYPE or chromosome, or to the output files of all samples
+func (splitter *outputSplitter) writeVariant(variant *Variant, Cctx *cli.Context) {
+	switch splitter.mode {
+	case "svtype":
+		splitter.output(splitSvtype(variant), Cctx).writeVariant(variant, Cctx)
+	case "chrom":
+		splitter.output(variant.Chromosome, Cctx).writeVariant(variant, Cctx)
+	case "sample":
+		for _, sample := range splitter.header.Samples {
+			sampleVariant := *variant
+			sampleVariant.Format = map[string]VariantFormat{sample: variant.Format[sample]}
+			splitter.output(sample, Cctx).writeVariant(&sampleVariant, Cctx)
+		}
+	}
+}
+
+// Get the output file of a key, the file is created and gets its header when it doesn't exist yet
+func (splitter *outputSplitter) output(key string, Cctx *cli.Context) *splitOutput {
+	logger := newLogger()
+
+	if output, ok := splitter.outputs[key]; ok {
+		return output
+	}
+
+	output := &splitOutput{header: splitter.header}
+	if splitter.mode == "sample" {
+		sampleHeader := *splitter.header
+		sampleHeader.Samples = []string{key}
+		output.header = &sampleHeader
+	}
+
+	output.path = strings.ReplaceAll(splitter.template, fmt.Sprintf("{%s}", splitter.mode), unsafeFileNameRegex.ReplaceAllString(key, "_"))
+	// Creating the file again would truncate the output of the other key
+	if other, ok := splitter.paths[output.path]; ok {
+		logger.Fatalf("The %s values '%s' and '%s' both write to the output file %s", splitter.mode, other, key, output.path)
+	}
+	splitter.paths[output.path] = key
+	output.file, output.close = createFile(output.path, splitter.format)
+
+	// Every output file needs its own BCF writer, the rest of the config is shared
+	outputConfig := *splitter.config
+	outputConfig.splitter = nil
+	outputConfig.bcf = nil
+	if splitter.format == "bcf" {
+		outputConfig.bcf = newBcfWriter(output.file, false)
+	}
+	output.config = &outputConfig
+
+	// The output is kept before its header is written, so it's also closed and removed when that fails
+	splitter.outputs[key] = output
+	splitter.keys = append(splitter.keys, key)
+	writeHeader(output.config, Cctx, output.header, output.file, false)
+	return output
+}
+
+// Write the variant to the output file
+func (output *splitOutput) writeVariant(variant *Variant, Cctx *cli.Context) {
+	variant.Header = output.header
+	writeVariant(output.config, Cctx, variant, output.file, false)
+}
+
+// Close all output files
+func (splitter *outputSplitter) close() {
+	for _, key := range splitter.keys {
+		output := splitter.outputs[key]
+		if output.config.bcf != nil {
+			output.config.bcf.close()
+		}
+		output.close()
+	}
+}
+
+// Remove all output files, used to clean up the output of a failed run
+func (splitter *outputSplitter) remove() {
+	for _, key := range splitter.keys {
+		os.Remove(splitter.outputs[key].path)
+	}
+}
+
+// Get the SVTYPE of a variant used to split the output, from the SVTYPE INFO field or from the ALT
+func splitSvtype(variant *Variant) string {
+	if svtype, ok := variant.Info["SVTYPE"]; ok && len(svtype) > 0 && svtype[0] != "" {
+		return svtype[0]
+	}
+	if svtype := variant.svtype(); svtype != "" {
+		return svtype
+	}
+	return "UNKNOWN"
+}
@@ -0,0 +1,101 @@
+package svync_api
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+func TestSplitOutput(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "input.vcf")
+	lines := []string{
+		`##fileformat=VCFv4.2`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+		`##INFO=<ID=END,Number=1,Type=Integer,Description="End position">`,
+		`##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">`,
+		`##contig=<ID=chr1,length=1000>`,
+		`##contig=<ID=chr2,length=1000>`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tS1\tS2",
+		"chr1\t100\tdel1\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL;END=200\tGT\t0/1\t0/0",
+		"chr1\t300\tdup1\tN\t<DUP>\t.\tPASS\tSVTYPE=DUP;END=400\tGT\t1/1\t0/1",
+		"chr2\t100\tdel2\tN\t<DEL>\t.\tPASS\tSVTYPE=DEL;END=200\tGT\t0/0\t1/1",
+	}
+	if err := os.WriteFile(input, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode  string
+		files map[string][]string
+	}{
+		{"svtype", map[string][]string{"DEL": {"split_1", "split_3"}, "DUP": {"split_2"}}},
+		{"chrom", map[string][]string{"chr1": {"split_1", "split_2"}, "chr2": {"split_3"}}},
+		{"sample", map[string][]string{"S1": {"split_1", "split_2", "split_3"}, "S2": {"split_1", "split_2", "split_3"}}},
+	}
+	for _, test := range tests {
+		directory := t.TempDir()
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.String("input", input, "")
+		flags.String("output", filepath.Join(directory, "out.{"+test.mode+"}.vcf"), "")
+		flags.String("split-by", test.mode, "")
+		flags.String("vcf-version", "4.2", "")
+		flags.Bool("mute-warnings", true, "")
+		flags.Bool("nodate", true, "")
+		Cctx := cli.NewContext(cli.NewApp(), flags, nil)
+
+		config := parseConfig([]byte("id: split\n"), Cctx)
+		Execute(Cctx, config)
+
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != len(test.files) {
+			t.Errorf("%s: wrote %d files, want %d", test.mode, len(entries), len(test.files))
+		}
+		for key, want := range test.files {
+			data, err := os.ReadFile(filepath.Join(directory, "out."+key+".vcf"))
+			if err != nil {
+				t.Errorf("%s: %v", test.mode, err)
+				continue
+			}
+			ids := []string{}
+			for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+				fields := strings.Split(line, "\t")
+				if strings.HasPrefix(line, "#CHROM") {
+					// Every sample gets its own file with only its own column
+					if test.mode == "sample" && !reflect.DeepEqual(fields[9:], []string{key}) {
+						t.Errorf("%s: the samples of %s are %v", test.mode, key, fields[9:])
+					}
+				} else if !strings.HasPrefix(line, "#") {
+					ids = append(ids, fields[2])
+				}
+			}
+			if !reflect.DeepEqual(ids, want) {
+				t.Errorf("%s: the variants of %s are %v, want %v", test.mode, key, ids, want)
+			}
+		}
+	}
+}
+
+func TestSplitSvtype(t *testing.T) {
+	tests := []struct {
+		variant *Variant
+		want    string
+	}{
+		{&Variant{Alt: "<DEL>", Info: map[string][]string{"SVTYPE": {"DEL"}}}, "DEL"},
+		{&Variant{Alt: "<INS:ME:ALU>", Info: map[string][]string{}}, "INS"},
+		{&Variant{Alt: "N[chr2:100[", Info: map[string][]string{}}, "BND"},
+		{&Variant{Alt: "A", Info: map[string][]string{}}, "UNKNOWN"},
+	}
+	for _, test := range tests {
+		if got := splitSvtype(test.variant); got != test.want {
+			t.Errorf("splitSvtype(%s) = %s, want %s", test.variant.Alt, got, test.want)
+		}
+	}
+}
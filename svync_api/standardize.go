@@ -14,34 +14,62 @@ import (
 )
 
 func writeHeader(config *Config, Cctx *cli.Context, header *Header, file *os.File, stdout bool) {
+	if config.splitter != nil {
+		config.splitter.writeHeader(config, Cctx, header)
+		return
+	}
+
+	switch outputFormat(Cctx) {
+	case "bedpe":
+		writeBedpeHeader(Cctx, header, file, stdout)
+		return
+	case "tsv":
+		writeTsvHeader(config, Cctx, header, file, stdout)
+		return
+	case "jsonl":
+		// JSON Lines files don't have a header
+		return
+	}
+
 	// VCF version
-	writeLine("##fileformat=VCFv4.2", file, stdout)
+	lines := []string{fmt.Sprintf("##fileformat=VCFv%s", config.vcfVersion)}
 
 	// Date of file creation
 	if !Cctx.Bool("nodate") {
 		cT := time.Now()
 		dateLine := fmt.Sprintf("##fileDate=%d%02d%02d", cT.Year(), cT.Month(), cT.Day())
-		writeLine(dateLine, file, stdout)
+		lines = append(lines, dateLine)
 	}
 
 	descriptionRegex := regexp.MustCompile(`["']?([^"']*)["']?`)
 
 	// ALT header lines
+	hasCnv := false
+	altIds := map[string]bool{}
 	for _, alt := range header.Alt {
-		altId := alt.Id
-		if newAlt, ok := config.Alt.Alts[altId]; ok {
-			altId = newAlt
+		altId := config.headerAltId(alt.Id)
+		// Multiple ALTs of the input can get the same new ALT
+		if altIds[altId] {
+			continue
+		}
+		altIds[altId] = true
+		if altId == "CNV" {
+			hasCnv = true
 		}
 		description := descriptionRegex.FindStringSubmatch(alt.Description)[1]
 		altLine := fmt.Sprintf("##ALT=<ID=%s,Description=\"%s\">", altId, description)
-		writeLine(altLine, file, stdout)
+		lines = append(lines, altLine)
+	}
+	// VCF 4.4 defines the CNV symbolic allele, add it when the input doesn't define it
+	if config.vcfVersion == "4.4" && !hasCnv {
+		lines = append(lines, "##ALT=<ID=CNV,Description=\"Copy number variable region\">")
 	}
 
 	// FILTER header lines
-	for _, filter := range header.Filter {
+	for _, filter := range config.filterHeaderLines(header) {
 		description := descriptionRegex.FindStringSubmatch(filter.Description)[1]
 		filterLine := fmt.Sprintf("##FILTER=<ID=%s,Description=\"%s\">", filter.Id, description)
-		writeLine(filterLine, file, stdout)
+		lines = append(lines, filterLine)
 	}
 
 	// Write the info fields of the config
@@ -49,7 +77,7 @@ func writeHeader(config *Config, Cctx *cli.Context, header *Header, file *os.Fil
 		description := descriptionRegex.FindStringSubmatch(info.Description)[1]
 		infoType := cases.Title(language.English, cases.Compact).String(strings.ToLower(info.Type))
 		infoLine := fmt.Sprintf("##INFO=<ID=%s,Number=%s,Type=%s,Description=\"%s\">", name, info.Number, infoType, description)
-		writeLine(infoLine, file, stdout)
+		lines = append(lines, infoLine)
 	}
 
 	// Write the format fields of the config
@@ -57,28 +85,119 @@ func writeHeader(config *Config, Cctx *cli.Context, header *Header, file *os.Fil
 		description := descriptionRegex.FindStringSubmatch(format.Description)[1]
 		formatType := cases.Title(language.English, cases.Compact).String(strings.ToLower(format.Type))
 		formatLine := fmt.Sprintf("##FORMAT=<ID=%s,Number=%s,Type=%s,Description=\"%s\">", name, format.Number, formatType, description)
-		writeLine(formatLine, file, stdout)
+		lines = append(lines, formatLine)
+	}
+
+	// Reference FASTA file
+	if config.reference != nil {
+		lines = append(lines, fmt.Sprintf("##reference=file://%s", config.reference.Path))
+
+		// Use the contigs of the reference when the input VCF has none
+		if len(header.Contig) == 0 {
+			header.Contig = config.reference.contigs()
+		}
 	}
 
 	// Write the contig fields
 	for _, contig := range header.Contig {
-		contigLine := fmt.Sprintf("##contig=<ID=%s,length=%d>", contig.Id, contig.Length)
-		writeLine(contigLine, file, stdout)
+		id := config.renameChromosome(contig.Id)
+		if !config.keepContig(id) {
+			continue
+		}
+		lines = append(lines, contig.headerLine(id))
 	}
 
 	// Write the column headers
-	columnHeaders := []string{"#CHROM", "POS", "ID", "REF", "ALT", "QUAL", "FILTER", "INFO", "FORMAT"}
-	columnHeaders = append(columnHeaders, header.Samples...)
-	writeLine(strings.Join(columnHeaders, "\t"), file, stdout)
+	columnHeaders := []string{"#CHROM", "POS", "ID", "REF", "ALT", "QUAL", "FILTER", "INFO"}
+	if len(header.Samples) > 0 {
+		columnHeaders = append(columnHeaders, "FORMAT")
+		columnHeaders = append(columnHeaders, header.Samples...)
+	}
+	lines = append(lines, strings.Join(columnHeaders, "\t"))
+
+	if config.bcf != nil {
+		config.bcf.writeHeader(lines)
+		return
+	}
+	for _, line := range lines {
+		writeLine(line, file, stdout)
+	}
+}
+
+// Convert the contig to a header line with the given ID
+// All other attributes are written in their original order
+func (contig *HeaderLineContig) headerLine(id string) string {
+	attributes := []string{}
+	hasId := false
+	hasLength := false
+	for _, attribute := range contig.Attributes {
+		switch strings.ToLower(attribute.Key) {
+		case "id":
+			attributes = append(attributes, fmt.Sprintf("%s=%s", attribute.Key, id))
+			hasId = true
+		case "length":
+			if contig.Length > 0 {
+				attributes = append(attributes, fmt.Sprintf("%s=%d", attribute.Key, contig.Length))
+				hasLength = true
+			}
+		default:
+			attributes = append(attributes, fmt.Sprintf("%s=%s", attribute.Key, attribute.Value))
+		}
+	}
+	if !hasId {
+		attributes = append([]string{fmt.Sprintf("ID=%s", id)}, attributes...)
+	}
+	if !hasLength && contig.Length > 0 {
+		attributes = append(attributes, fmt.Sprintf("length=%d", contig.Length))
+	}
+	return fmt.Sprintf("##contig=<%s>", strings.Join(attributes, ","))
 }
 
 // Standardize the VCF file and write it to the output file
 func standardizeAndOutput(config *Config, Cctx *cli.Context, variant *Variant, file *os.File, stdout bool, variantCount int) {
 
 	// Standardize the variant
-	line := variant.standardize(config, Cctx, variantCount).String(config)
-	writeLine(line, file, stdout)
+	standardizedVariant := variant.standardize(config, Cctx, variantCount)
+	if !config.keepVariant(variant, standardizedVariant) {
+		return
+	}
+	if config.Dedup != nil {
+		config.Dedup.add(variant, standardizedVariant, Cctx, config)
+		return
+	}
+	outputVariant(config, Cctx, standardizedVariant, file, stdout)
+}
 
+// Get the ID of an ALT header line after the alts of the config are applied
+// The alts are looked up along the hierarchy of the ID, like the ALTs of the variants
+// Only values that are a fixed symbolic allele (e.g. <INS:ME>) change the ID, other values keep the ID of the input
+func (config *Config) headerAltId(id string) string {
+	newAlt, ok := config.Alt.Alts.get(symbolicAltKeys(id))
+	if !ok || !strings.HasPrefix(newAlt, "<") || !strings.HasSuffix(newAlt, ">") || strings.ContainsAny(newAlt, "$~") {
+		return id
+	}
+	return newAlt[1 : len(newAlt)-1]
+}
+
+// Write the variant in the output format
+func writeVariant(config *Config, Cctx *cli.Context, variant *Variant, file *os.File, stdout bool) {
+	if config.splitter != nil {
+		config.splitter.writeVariant(variant, Cctx)
+		return
+	}
+
+	switch outputFormat(Cctx) {
+	case "bedpe":
+		writeLine(variant.bedpe(Cctx.StringSlice("columns")), file, stdout)
+	case "tsv":
+		writeLine(variant.tsv(config, config.tsvColumns(Cctx)), file, stdout)
+	case "jsonl":
+		writeLine(variant.jsonl(config), file, stdout)
+	case "bcf":
+		config.bcf.writeVariant(variant)
+	default:
+		writeLine(variant.String(config), file, stdout)
+	}
 }
 
 // Standardize the variant using the config
@@ -86,36 +205,52 @@ func (variant *Variant) standardize(config *Config, Cctx *cli.Context, count int
 	standardizedVariant := newVariant()
 	standardizedVariant.Chromosome = variant.Chromosome
 	standardizedVariant.Pos = variant.Pos
-	standardizedVariant.Ref = variant.Ref
+	standardizedVariant.Ref = config.standardizeRef(variant, Cctx)
 	standardizedVariant.Alt = variant.Alt
-	standardizedVariant.Qual = variant.Qual
-	standardizedVariant.Filter = variant.Filter
+	standardizedVariant.Qual = config.standardizeQual(variant, Cctx)
+	standardizedVariant.Filter = config.standardizeFilter(variant, Cctx)
 	standardizedVariant.Header = variant.Header
 
-	sVType := variant.Info["SVTYPE"][0]
+	altKeys := variant.altKeys()
+	rule := config.matchRule(variant, Cctx)
 
-	if config.Alt.Alts != nil {
-		if alt, ok := config.Alt.Alts[sVType]; ok {
-			standardizedVariant.Alt = ResolveValue(alt, variant, nil, Cctx, config)
-		}
-	}
-
-	if config.Alt.Value != "" {
+	// A matching rule takes precedence over the alts, which take precedence over the value
+	if rule != nil && rule.Then.Alt != "" {
+		standardizedVariant.Alt = ResolveValue(rule.Then.Alt, variant, nil, Cctx, config)
+	} else if alt, ok := config.Alt.Alts.get(altKeys); ok {
+		standardizedVariant.Alt = ResolveValue(alt, variant, nil, Cctx, config)
+	} else if config.Alt.Value != "" {
 		standardizedVariant.Alt = ResolveValue(config.Alt.Value, variant, nil, Cctx, config)
 	}
 
-	standardizedVariant.Id = fmt.Sprintf("%s_%v", ResolveValue(config.Id, variant, nil, Cctx, config), count)
+	id := config.Id
+	if rule != nil && rule.Then.Id != "" {
+		id = rule.Then.Id
+	}
+	standardizedVariant.Id = fmt.Sprintf("%s_%v", ResolveValue(id, variant, nil, Cctx, config), count)
 
 	// Add info fields
 	for name, infoConfig := range config.Info {
 		value := infoConfig.Value
-		if val, ok := config.Info[name].Alts[sVType]; ok {
+		if val, ok := infoConfig.Alts.get(altKeys); ok {
 			value = val
 		}
+		if rule != nil {
+			if val, ok := rule.Then.Info[name]; ok {
+				value = val
+			}
+		}
 		// Don't add INFO fields with empty values
 		if value == "" {
 			continue
 		}
+		// Only add flags when their value resolves to true
+		if strings.EqualFold(infoConfig.Type, "Flag") {
+			if evaluateCondition(ResolveValue(value, variant, nil, Cctx, config)) {
+				standardizedVariant.Info[name] = []string{}
+			}
+			continue
+		}
 		standardizedVariant.Info[name] = []string{ResolveValue(value, variant, nil, Cctx, config)}
 	}
 
@@ -126,16 +261,42 @@ func (variant *Variant) standardize(config *Config, Cctx *cli.Context, count int
 
 		for name, formatConfig := range config.Format {
 			value := formatConfig.Value
-			if val, ok := formatConfig.Alts[sVType]; ok {
+			if val, ok := formatConfig.Alts.get(altKeys); ok {
 				value = val
 			}
+			if rule != nil {
+				if val, ok := rule.Then.Format[name]; ok {
+					value = val
+				}
+			}
 			newFormat.Content[name] = []string{ResolveValue(value, variant, &format, Cctx, config)}
 		}
 		standardizedVariant.Format[sample] = *newFormat
 	}
+
+	config.renameChromosomes(standardizedVariant)
+	if direction := Cctx.String("normalize"); direction != "" {
+		config.normalizeBreakpoints(standardizedVariant, direction, Cctx)
+	}
+	if maxSize := Cctx.Int64("to-literal"); maxSize > 0 {
+		config.toLiteral(standardizedVariant, maxSize, Cctx)
+	}
+	config.applyVersionConventions(standardizedVariant)
 	return standardizedVariant
 }
 
+// Return the first rule of which the condition is met by the variant
+// Returns nil when no rule matches
+func (config *Config) matchRule(variant *Variant, Cctx *cli.Context) *ConfigRule {
+	for index := range config.Rules {
+		rule := &config.Rules[index]
+		if evaluateCondition(ResolveValue(rule.When, variant, nil, Cctx, config)) {
+			return rule
+		}
+	}
+	return nil
+}
+
 // Initialize a new Variant
 func newVariant() *Variant {
 	return &Variant{
@@ -176,20 +337,34 @@ func (v *Variant) String(config *Config) string {
 	sort.Strings(infoKeys)
 
 	for _, key := range infoKeys {
-		if config.Info[key].Type == "Flag" {
+		if strings.EqualFold(config.Info[key].Type, "Flag") {
 			infoSlice = append(infoSlice, key)
 			continue
 		}
 		value := v.Info[key]
-		if value[0] == "" && len(value) == 1 {
+		if len(value) == 0 || (len(value) == 1 && value[0] == "") {
 			continue
 		}
-		infoSlice = append(infoSlice, fmt.Sprintf("%s=%s", key, strings.Join(value, ",")))
+		infoSlice = append(infoSlice, fmt.Sprintf("%s=%s", key, config.encodeValues(value, ";=")))
 	}
 
-	// Make sure the order of the format fields is respected
+	// The samples are written in the order of the header
 	samples := v.Header.Samples
-	sort.Strings(samples)
+
+	// Files without samples have no FORMAT column
+	if len(samples) == 0 {
+		return fmt.Sprintf(
+			"%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v",
+			v.Chromosome,
+			v.Pos,
+			v.Id,
+			v.Ref,
+			v.Alt,
+			v.Qual,
+			v.Filter,
+			strings.Join(infoSlice, ";"),
+		)
+	}
 
 	formatKeys := []string{}
 	for k := range v.Format[samples[0]].Content {
@@ -203,7 +378,7 @@ func (v *Variant) String(config *Config) string {
 	for _, sample := range samples {
 		sampleArray := []string{}
 		for _, key := range formatKeys {
-			sampleArray = append(sampleArray, strings.Join(v.Format[sample].Content[key], ","))
+			sampleArray = append(sampleArray, config.encodeValues(v.Format[sample].Content[key], ":"))
 		}
 		formatString += fmt.Sprintf("\t%s", strings.Join(sampleArray, ":"))
 	}
@@ -0,0 +1,31 @@
+package svync_api
+
+import "testing"
+
+func TestVariantStringInfo(t *testing.T) {
+	config := &Config{Info: MapConfigInput{
+		"SVTYPE":    {Type: "String"},
+		"IMPRECISE": {Type: "Flag"},
+		"CIPOS":     {Type: "Integer"},
+		"NOTE":      {Type: "String"},
+	}}
+
+	tests := []struct {
+		name string
+		info map[string][]string
+		want string
+	}{
+		{"single value", map[string][]string{"SVTYPE": {"DEL"}}, "SVTYPE=DEL"},
+		{"list value", map[string][]string{"CIPOS": {"-10", "10"}}, "CIPOS=-10,10"},
+		{"flag", map[string][]string{"IMPRECISE": {}, "SVTYPE": {"DEL"}}, "IMPRECISE;SVTYPE=DEL"},
+		{"empty value", map[string][]string{"NOTE": {""}, "SVTYPE": {"DEL"}}, "SVTYPE=DEL"},
+		{"no values", map[string][]string{"NOTE": {}, "SVTYPE": {"DEL"}}, "SVTYPE=DEL"},
+	}
+	for _, test := range tests {
+		variant := &Variant{Chromosome: "chr1", Pos: 100, Id: "var1", Ref: "N", Alt: "<DEL>", Qual: ".", Filter: "PASS", Header: newHeader(), Info: test.info}
+		want := "chr1\t100\tvar1\tN\t<DEL>\t.\tPASS\t" + test.want
+		if got := variant.String(config); got != want {
+			t.Errorf("%s: String() = %q, want %q", test.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package svync_api
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// The regex used to find the file statistics in resolvable values
+var statisticsRegex = regexp.MustCompile(`\$STATS/(QUAL|(?:INFO|FORMAT)/\w+)/(min|max|mean|median|count|p\d+(?:\.\d+)?)`)
+
+// A struct containing all numeric values of a field in the VCF file
+type fieldStatistics struct {
+	// All values of the field
+	values []float64
+
+	// A status flag indicating if the values have been sorted
+	sorted bool
+}
+
+// Add a value to the statistics
+func (stats *fieldStatistics) add(value float64) {
+	stats.values = append(stats.values, value)
+	stats.sorted = false
+}
+
+// Sort the values when they haven't been sorted yet
+func (stats *fieldStatistics) sort() {
+	if !stats.sorted {
+		sort.Float64s(stats.values)
+		stats.sorted = true
+	}
+}
+
+// Get the percentage of values that are lower than or equal to the given value
+func (stats *fieldStatistics) percentileRank(value float64) float64 {
+	if len(stats.values) == 0 {
+		return 0
+	}
+	stats.sort()
+	count := sort.Search(len(stats.values), func(i int) bool { return stats.values[i] > value })
+	return float64(count) / float64(len(stats.values)) * 100
+}
+
+// Get the value at the given percentile (0-100), interpolating between the closest ranks
+func (stats *fieldStatistics) percentile(percentile float64) float64 {
+	stats.sort()
+	rank := percentile / 100 * float64(len(stats.values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return stats.values[lower] + (stats.values[upper]-stats.values[lower])*(rank-float64(lower))
+}
+
+// Get the statistic with the given name (min, max, mean, median, count or p<percentile>)
+func (stats *fieldStatistics) get(name string) string {
+	logger := newLogger()
+
+	if name == "count" {
+		return fmt.Sprint(len(stats.values))
+	}
+	if len(stats.values) == 0 {
+		return "."
+	}
+
+	switch name {
+	case "min":
+		return floatToString(stats.percentile(0))
+	case "max":
+		return floatToString(stats.percentile(100))
+	case "median":
+		return floatToString(stats.percentile(50))
+	case "mean":
+		total := 0.0
+		for _, value := range stats.values {
+			total += value
+		}
+		return floatToString(total / float64(len(stats.values)))
+	}
+
+	percentile, err := strconv.ParseFloat(strings.TrimPrefix(name, "p"), 64)
+	if err != nil || percentile < 0 || percentile > 100 {
+		logger.Fatalf("Invalid percentile '%s', use a value between p0 and p100", name)
+	}
+	return floatToString(stats.percentile(percentile))
+}
+
+// Get all fields of which statistics are used in the config (e.g. QUAL, INFO/DP or FORMAT/DP)
+func (config *Config) statisticsFields() []string {
+	fields := []string{}
+	for _, value := range config.resolvableValues() {
+		for _, match := range statisticsRegex.FindAllStringSubmatch(value, -1) {
+			if !slices.Contains(fields, match[1]) {
+				fields = append(fields, match[1])
+			}
+		}
+	}
+	return fields
+}
+
+// Get all values of the config that are resolved for each variant
+func (config *Config) resolvableValues() []string {
+	values := []string{config.Id, config.Alt.Value}
+	values = append(values, config.Alt.Alts.values()...)
+	values = append(values, config.Qual.values()...)
+	for _, filter := range config.Filter.Filters {
+		values = append(values, filter.Value)
+	}
+	for _, fields := range []MapConfigInput{config.Info, config.Format} {
+		for _, field := range fields {
+			values = append(values, field.Value)
+			values = append(values, field.Alts.values()...)
+		}
+	}
+	for _, rule := range config.Rules {
+		values = append(values, rule.When, rule.Then.Id, rule.Then.Alt)
+		for _, value := range rule.Then.Info {
+			values = append(values, value)
+		}
+		for _, value := range rule.Then.Format {
+			values = append(values, value)
+		}
+	}
+	if config.Dedup != nil {
+		values = append(values, config.Dedup.Score)
+	}
+	return values
+}
+
+// Get all values of the QUAL field in the config
+func (qual *ConfigQual) values() []string {
+	return append([]string{qual.Value}, qual.Alts.values()...)
+}
+
+// Get the values of all alts
+func (alts ConfigAlts) values() []string {
+	values := []string{}
+	for _, value := range alts {
+		values = append(values, value)
+	}
+	return values
+}
+
+// Gather the statistics needed by the config in a first pass through the file
+func (config *Config) gatherStatistics(file string, header *Header, inputFormat string, Cctx *cli.Context) {
+	fields := config.statisticsFields()
+	rescaleQual := config.Qual.Rescale.Method == "percentile"
+
+	header.statistics = map[string]*fieldStatistics{}
+	for _, field := range fields {
+		header.statistics[field] = &fieldStatistics{}
+	}
+	if rescaleQual {
+		header.qualStatistics = &fieldStatistics{}
+	}
+
+	addValues := func(field string, values []string) {
+		stats, ok := header.statistics[field]
+		if !ok {
+			return
+		}
+		for _, value := range values {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				stats.add(number)
+			}
+		}
+	}
+
+	firstPassHeader := newHeader()
+	readInput(file, inputFormat, func(line string) {
+		var variant *Variant
+		if isBedFormat(inputFormat) {
+			if line == "" || isBedComment(line) {
+				return
+			}
+			variant = config.Input.createVariant(line, header, inputFormat)
+		} else if strings.HasPrefix(line, "#") {
+			firstPassHeader.parse(line)
+			return
+		} else if line == "" {
+			return
+		} else {
+			variant = createVariant(line, firstPassHeader, Cctx)
+		}
+
+		addValues("QUAL", []string{variant.Qual})
+		for field, values := range variant.Info {
+			addValues("INFO/"+field, values)
+		}
+		for _, format := range variant.Format {
+			for field, values := range format.Content {
+				addValues("FORMAT/"+field, values)
+			}
+		}
+
+		if rescaleQual {
+			if value, err := strconv.ParseFloat(config.resolveQual(variant, Cctx), 64); err == nil {
+				header.qualStatistics.add(value)
+			}
+		}
+	})
+}
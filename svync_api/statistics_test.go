@@ -0,0 +1,190 @@
+package svync_api
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// Create a context for the two-pass mode
+func twoPassContext() *cli.Context {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("vcf-version", "4.2", "")
+	flags.Bool("mute-warnings", true, "")
+	flags.Bool("two-pass", true, "")
+	return cli.NewContext(cli.NewApp(), flags, nil)
+}
+
+func TestFieldStatisticsGet(t *testing.T) {
+	stats := &fieldStatistics{}
+	for _, value := range []float64{40, 10, 30, 20} {
+		stats.add(value)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"count", "4"},
+		{"min", "10"},
+		{"max", "40"},
+		{"mean", "25"},
+		{"median", "25"},
+		{"p0", "10"},
+		{"p100", "40"},
+		{"p50", "25"},
+		{"p25", "17.5"},
+	}
+	for _, test := range tests {
+		if got := stats.get(test.name); got != test.want {
+			t.Errorf("get(%s) = %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	empty := &fieldStatistics{}
+	if got := empty.get("count"); got != "0" {
+		t.Errorf("get(count) of no values = %s, want 0", got)
+	}
+	if got := empty.get("median"); got != "." {
+		t.Errorf("get(median) of no values = %s, want .", got)
+	}
+}
+
+func TestFieldStatisticsPercentileRank(t *testing.T) {
+	stats := &fieldStatistics{}
+	for _, value := range []float64{10, 20, 30, 40} {
+		stats.add(value)
+	}
+
+	tests := []struct {
+		value float64
+		want  float64
+	}{
+		{5, 0},
+		{10, 25},
+		{25, 50},
+		{40, 100},
+		{50, 100},
+	}
+	for _, test := range tests {
+		if got := stats.percentileRank(test.value); got != test.want {
+			t.Errorf("percentileRank(%v) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestStatisticsFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{"no statistics", `
+info:
+  DP:
+    value: $INFO/DP
+`, []string{}},
+		{"info and format values", `
+info:
+  RDP:
+    value: ~sub:$INFO/DP,$STATS/INFO/DP/median
+format:
+  RDP:
+    value: ~sub:$FORMAT/DP,$STATS/FORMAT/DP/mean
+`, []string{"FORMAT/DP", "INFO/DP"}},
+		{"alts, filters, rules and qual", `
+alt:
+  alts:
+    DEL: $STATS/INFO/SVLEN/min
+qual:
+  alts:
+    DEL: $STATS/QUAL/max
+filter:
+  filters:
+    LowQual:
+      value: $QUAL < $STATS/QUAL/p10
+      description: Low quality
+rules:
+  - when: $INFO/SU > $STATS/INFO/SU/p90
+    then:
+      id: high_support
+`, []string{"INFO/SU", "INFO/SVLEN", "QUAL"}},
+		{"descriptions are not resolved", `
+info:
+  DP:
+    value: $INFO/DP
+    description: Compare with $STATS/INFO/DP/median
+`, []string{}},
+	}
+	for _, test := range tests {
+		config := parseConfig([]byte(test.config), twoPassContext())
+		got := config.statisticsFields()
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: statisticsFields() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseConfigRejectsStatisticsInPercentileQual(t *testing.T) {
+	defer func() {
+		recovered := recover()
+		err, ok := recovered.(fatalError)
+		if !ok {
+			t.Fatalf("parseConfig() recovered %v, want a fatal error", recovered)
+		}
+		if !strings.Contains(err.Error(), "percentile") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}()
+	parseConfig([]byte(`
+qual:
+  value: ~sub:$QUAL,$STATS/QUAL/median
+  rescale:
+    method: percentile
+`), twoPassContext())
+}
+
+func TestGatherStatistics(t *testing.T) {
+	Cctx := twoPassContext()
+	config := parseConfig([]byte(`
+qual:
+  rescale:
+    method: percentile
+filter:
+  filters:
+    LowDepth:
+      value: $INFO/DP < $STATS/INFO/DP/median
+      description: Depth below the median
+`), Cctx)
+
+	path := filepath.Join(t.TempDir(), "test.vcf")
+	lines := []string{
+		`##fileformat=VCFv4.2`,
+		`##INFO=<ID=DP,Number=1,Type=Integer,Description="Depth">`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO",
+		"chr1\t100\tdel1\tN\t<DEL>\t10\tPASS\tDP=5",
+		"chr1\t200\tdel2\tN\t<DEL>\t.\tPASS\tDP=15",
+		"chr1\t300\tdel3\tN\t<DEL>\t30\tPASS\tDP=10",
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	header := newHeader()
+	config.gatherStatistics(path, header, "vcf", Cctx)
+
+	if got := header.statistics["INFO/DP"].get("median"); got != "10" {
+		t.Errorf("median of INFO/DP = %s, want 10", got)
+	}
+	// Missing QUAL values aren't part of the percentile ranks
+	if got := header.qualStatistics.get("count"); got != "2" {
+		t.Errorf("count of QUAL = %s, want 2", got)
+	}
+}
@@ -1,5 +1,7 @@
 package svync_api
 
+import "regexp"
+
 // The struct representing the header of the input VCF file in a parseable format
 type Header struct {
 	// Object containing the INFO fields with their ID, Number, Type and Description
@@ -22,14 +24,31 @@ type Header struct {
 	// The value is a struct containing the Id and Description
 	Filter map[string]HeaderLineIdDescription
 
-	// List of all contigs in the VCF file with their ID and Length
-	Contig []HeaderLineIdLength
+	// List of all contigs in the VCF file with their ID, Length and other attributes
+	Contig []HeaderLineContig
 
 	// List of all other VCF fields
 	Other []string
 
 	// List of all samples in the VCF file
 	Samples []string
+
+	// The QUAL values of all variants in the VCF file
+	// This is only gathered when the QUAL values are rescaled to percentile ranks
+	qualStatistics *fieldStatistics
+
+	// The values of all fields used in $STATS variables, the key is the field (e.g. QUAL, INFO/DP or FORMAT/DP)
+	// This is only gathered in the two-pass mode
+	statistics map[string]*fieldStatistics
+
+	// The IDs of the FILTER, INFO and FORMAT fields in the order of the dictionary of strings in BCF files
+	dictionary []string
+
+	// The IDs of the contigs in the order of the dictionary of contigs in BCF files
+	contigDictionary []string
+
+	// The VCF version of the file (e.g. 4.2), taken from the ##fileformat header line
+	version string
 }
 
 // A struct representing a header line in the VCF file with its ID and Description
@@ -62,13 +81,26 @@ type HeaderLineIdNumberTypeDescription struct {
 	Description string
 }
 
-// A struct representing a header line in the VCF file with its ID and Length
-type HeaderLineIdLength struct {
-	// The ID of the header line
+// A struct representing a contig header line in the VCF file
+type HeaderLineContig struct {
+	// The ID of the contig
 	Id string
 
-	// The length of the header line
+	// The length of the contig, 0 when the length is unknown
 	Length int64
+
+	// All attributes of the header line (including the ID and length) in their original order
+	// e.g. ID, length, assembly, md5, species and URL
+	Attributes []HeaderLineAttribute
+}
+
+// A struct representing a key-value pair in a header line
+type HeaderLineAttribute struct {
+	// The key of the attribute as written in the header line
+	Key string
+
+	// The value of the attribute as written in the header line (including quotes)
+	Value string
 }
 
 // A struct representing a variant in the input VCF file
@@ -126,15 +158,53 @@ type Config struct {
 	// How to handle the ID field of each variant
 	Id string
 
+	// How to read the input file
+	Input ConfigInputFile
+
+	// How to handle the chromosome names
+	Chromosomes ConfigChromosomes
+
 	// How to handle the ALT field of each variant
 	// A value can be given for each SVTYPE
 	Alt ConfigSimpleInput
 
+	// How to handle the QUAL field of each variant
+	Qual ConfigQual
+
+	// How to handle the FILTER field of each variant
+	Filter ConfigFilter
+
 	// How to handle the INFO fields of each variant
 	Info MapConfigInput
 
 	// How to handle the FORMAT fields of each variant
 	Format MapConfigInput
+
+	// Rules that override the configuration for the variants that match them
+	// Only the first matching rule is applied to a variant
+	Rules []ConfigRule
+
+	// How to deduplicate the redundant variants within the input file
+	// The variants aren't deduplicated when this section is missing
+	Dedup *ConfigDedup
+
+	// The reference FASTA file given with --reference
+	reference *Reference
+
+	// The BCF output file, only used when the output format is BCF
+	bcf *bcfWriter
+
+	// The VCF version of the output given with --vcf-version
+	vcfVersion string
+
+	// The sorter of the output variants, only used when the output is sorted
+	sorter *variantSorter
+
+	// The writer of the split output files, only used when the output is split with --split-by
+	splitter *outputSplitter
+
+	// The path of the output file once it has been created, empty when the output is written to stdout or split
+	outputPath string
 }
 
 // A struct representing a simple configuration of a field
@@ -144,9 +214,108 @@ type ConfigSimpleInput struct {
 	Value string
 
 	// Alternative values for each SVTYPE
-	Alts map[string]string
+	Alts ConfigAlts
+}
+
+// A struct representing the configuration of the input file
+type ConfigInputFile struct {
+	// The format of the input file, can be "vcf", "bcf", "bedpe" or "bed"
+	// Defaults to the extension of the input file
+	Format string
+
+	// The 1-based column numbers of the fields in BEDPE and BED files
+	// BEDPE: chrom1, start1, end1, chrom2, start2, end2, name, score, strand1, strand2, svtype and filter
+	// BED: chrom, start, end, name, score, strand, svtype and filter
+	Columns map[string]int
+
+	// The 1-based column numbers of extra columns that are added as INFO fields
+	// The key is the name of the INFO field
+	Info map[string]int
+
+	// The SVTYPE of the variants when there is no svtype column
+	Svtype string
+}
+
+// A struct representing the configuration of the chromosome names
+type ConfigChromosomes struct {
+	// The built-in aliases to use for the primary contigs of GRCh37/GRCh38, can be "ucsc" or "ensembl"
+	// ucsc = rename 1, 2, ..., MT to chr1, chr2, ..., chrM
+	// ensembl = rename chr1, chr2, ..., chrM to 1, 2, ..., MT
+	Style string
+
+	// Map the chromosome names of the input to new chromosome names
+	Rename map[string]string
+
+	// Only keep the variants on contigs that match one of these patterns (regular expressions)
+	Include []string
+
+	// Remove the variants on contigs that match one of these patterns (regular expressions)
+	Exclude []string
+
+	// The combined mapping of the style, the renames and the mapping file
+	mapping map[string]string
+
+	// The compiled include and exclude patterns
+	includeRegexes []*regexp.Regexp
+	excludeRegexes []*regexp.Regexp
+}
+
+// A struct representing the configuration of the QUAL field
+type ConfigQual struct {
+	// The value of the field
+	// This can be a string or a reference to another field
+	Value string
+
+	// Alternative values for each SVTYPE
+	Alts ConfigAlts
+
+	// How to rescale the QUAL values so they can be compared between callers
+	Rescale ConfigRescale
+}
+
+// A struct representing the rescaling of numeric values
+type ConfigRescale struct {
+	// The rescaling method, can be "percentile" or "logistic"
+	// percentile = the percentile rank of the value within the VCF file (0-100)
+	// logistic = a logistic transformation of the value (0-scale)
+	Method string
+
+	// The value that gets rescaled to half of the scale in the logistic transformation
+	Midpoint float64
+
+	// The steepness of the curve in the logistic transformation
+	Steepness float64
+
+	// The maximum value of the logistic transformation, defaults to 100
+	Scale float64
+}
+
+// A struct representing the configuration of the FILTER field
+type ConfigFilter struct {
+	// Map the filters of the caller to the standardized filters
+	// An empty value removes the filter from the variants
+	Map map[string]string
+
+	// The standardized filters with their description
+	// Filters with a value get added to the variants of which the value resolves to true
+	Filters map[string]ConfigFilterInput
+}
+
+// A struct representing the configuration of a standardized filter
+type ConfigFilterInput struct {
+	// The condition that adds the filter to a variant
+	// This is resolved and evaluated as a condition
+	Value string
+
+	// The description of the filter
+	// This is used to generate the VCF header
+	Description string
 }
 
+// A map construct for alternative values for each ALT or SVTYPE
+// The keys can contain wildcards and the values are looked up along the symbolic ALT hierarchy
+type ConfigAlts map[string]string
+
 // A map construct for advanced configurations
 type MapConfigInput map[string]ConfigInput
 
@@ -170,5 +339,52 @@ type ConfigInput struct {
 	Type string
 
 	// Alternative values for each SVTYPE
-	Alts map[string]string
+	Alts ConfigAlts
+}
+
+// A struct representing the deduplication of the variants within the input file
+type ConfigDedup struct {
+	// The maximum distance between the breakpoints of duplicate variants
+	Distance int64
+
+	// The minimum reciprocal overlap of duplicate deletions, duplications, inversions and copy number variants (0-1)
+	Overlap float64
+
+	// The minimum ratio between the lengths of duplicate variants (0-1)
+	Similarity float64
+
+	// The score of a variant, the variant with the highest score of the duplicates is kept
+	// This is resolved on the input variant and defaults to $QUAL
+	Score string
+
+	// The INFO field that gets the input IDs of the removed duplicates, defaults to DEDUP_IDS
+	Info string
+
+	// The standardized variants that wait for the deduplication
+	variants []*dedupVariant
+}
+
+// A struct representing a rule that applies overrides to the variants matching its condition
+type ConfigRule struct {
+	// The condition that a variant has to meet for the rule to be applied
+	// This is resolved and evaluated as a condition
+	When string
+
+	// The overrides that are applied when the condition is met
+	Then ConfigRuleOverrides
+}
+
+// A struct representing the overrides of a rule
+type ConfigRuleOverrides struct {
+	// The value of the ID field
+	Id string
+
+	// The value of the ALT field
+	Alt string
+
+	// The values of the INFO fields, an empty value removes the field
+	Info map[string]string
+
+	// The values of the FORMAT fields
+	Format map[string]string
 }
@@ -0,0 +1,221 @@
+package svync_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// The fixed columns that can be used in the TSV output
+var fixedColumns = []string{"CHROM", "POS", "ID", "REF", "ALT", "QUAL", "FILTER"}
+
+// A struct representing a variant in the JSON Lines output
+type jsonRecord struct {
+	Chrom   string                            `json:"chrom"`
+	Pos     int64                             `json:"pos"`
+	Id      string                            `json:"id"`
+	Ref     string                            `json:"ref"`
+	Alt     []string                          `json:"alt"`
+	Qual    interface{}                       `json:"qual"`
+	Filter  []string                          `json:"filter"`
+	Info    map[string]interface{}            `json:"info"`
+	Samples map[string]map[string]interface{} `json:"samples,omitempty"`
+}
+
+// Get the columns of the TSV output
+// Defaults to the fixed columns followed by all INFO and FORMAT fields of the config
+func (config *Config) tsvColumns(Cctx *cli.Context) []string {
+	if columns := Cctx.StringSlice("columns"); len(columns) > 0 {
+		return columns
+	}
+	columns := append([]string{}, fixedColumns...)
+	for _, name := range sortedKeys(config.Info) {
+		columns = append(columns, "INFO/"+name)
+	}
+	for _, name := range sortedKeys(config.Format) {
+		columns = append(columns, "FORMAT/"+name)
+	}
+	return columns
+}
+
+// Write the column headers of the TSV file
+func writeTsvHeader(config *Config, Cctx *cli.Context, header *Header, file *os.File, stdout bool) {
+	logger := newLogger()
+
+	columnHeaders := []string{}
+	for _, column := range config.tsvColumns(Cctx) {
+		split := strings.SplitN(column, "/", 2)
+		switch {
+		case len(split) == 1 && isFixedColumn(column):
+			columnHeaders = append(columnHeaders, column)
+		case len(split) == 2 && (split[0] == "INFO" || split[0] == "FORMAT"):
+			columnHeaders = append(columnHeaders, extraColumnHeaders([]string{column}, header.Samples)...)
+		default:
+			logger.Fatalf("Invalid TSV column '%s', use one of %s, INFO/<field> or FORMAT/<field>", column, strings.Join(fixedColumns, ", "))
+		}
+	}
+	writeLine(strings.Join(columnHeaders, "\t"), file, stdout)
+}
+
+// Check if the column is one of the fixed VCF columns
+func isFixedColumn(column string) bool {
+	for _, fixed := range fixedColumns {
+		if column == fixed {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert a standardized variant to a TSV line
+// Missing values are left empty and flags are written as true or false
+func (v *Variant) tsv(config *Config, columns []string) string {
+	values := []string{}
+	for _, column := range columns {
+		split := strings.SplitN(column, "/", 2)
+		switch split[0] {
+		case "CHROM":
+			values = append(values, v.Chromosome)
+		case "POS":
+			values = append(values, fmt.Sprint(v.Pos))
+		case "ID":
+			values = append(values, emptyIfMissing(v.Id))
+		case "REF":
+			values = append(values, v.Ref)
+		case "ALT":
+			values = append(values, emptyIfMissing(v.Alt))
+		case "QUAL":
+			values = append(values, emptyIfMissing(v.Qual))
+		case "FILTER":
+			values = append(values, emptyIfMissing(v.Filter))
+		case "INFO":
+			if strings.EqualFold(config.Info[split[1]].Type, "Flag") {
+				_, ok := v.Info[split[1]]
+				values = append(values, strconv.FormatBool(ok))
+				continue
+			}
+			values = append(values, v.extraColumns([]string{column}, "")...)
+		case "FORMAT":
+			values = append(values, v.extraColumns([]string{column}, "")...)
+		}
+	}
+	return strings.Join(values, "\t")
+}
+
+// Return an empty string for missing values (.)
+func emptyIfMissing(value string) string {
+	if value == "." {
+		return ""
+	}
+	return value
+}
+
+// Convert a standardized variant to a JSON object on a single line
+// The INFO and FORMAT values are converted to numbers and booleans using their type in the config
+func (v *Variant) jsonl(config *Config) string {
+	logger := newLogger()
+
+	record := jsonRecord{
+		Chrom:  v.Chromosome,
+		Pos:    v.Pos,
+		Id:     v.Id,
+		Ref:    v.Ref,
+		Alt:    strings.Split(v.Alt, ","),
+		Qual:   typedValue(v.Qual, "Float"),
+		Filter: nil,
+		Info:   map[string]interface{}{},
+	}
+	if v.Filter != "." && v.Filter != "" {
+		record.Filter = strings.Split(v.Filter, ";")
+	}
+
+	for name, info := range config.Info {
+		if strings.EqualFold(info.Type, "Flag") {
+			_, ok := v.Info[name]
+			record.Info[name] = ok
+			continue
+		}
+		if values, ok := v.Info[name]; ok && !isEmpty(values) {
+			record.Info[name] = typedValues(values, info.Number, info.Type)
+		}
+	}
+
+	samples := append([]string{}, v.Header.Samples...)
+	sort.Strings(samples)
+	if len(samples) > 0 {
+		record.Samples = map[string]map[string]interface{}{}
+	}
+	for _, sample := range samples {
+		content := map[string]interface{}{}
+		for name, format := range config.Format {
+			if values, ok := v.Format[sample].Content[name]; ok && !isEmpty(values) {
+				content[name] = typedValues(values, format.Number, format.Type)
+			}
+		}
+		record.Samples[sample] = content
+	}
+
+	// Don't escape the brackets of symbolic alleles
+	line := &bytes.Buffer{}
+	encoder := json.NewEncoder(line)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(record); err != nil {
+		logger.Fatalf("Failed to convert the variant with ID %s to JSON: %v", v.Id, err)
+	}
+	return strings.TrimSuffix(line.String(), "\n")
+}
+
+// Check if the field has no value, these fields are left out of the output
+func isEmpty(values []string) bool {
+	return len(values) == 1 && values[0] == ""
+}
+
+// Convert the values of an INFO or FORMAT field to their type
+// Fields with Number=1 become a single value, all other fields become a list
+func typedValues(values []string, number string, fieldType string) interface{} {
+	// Resolved values can contain multiple comma-separated values
+	values = strings.Split(strings.Join(values, ","), ",")
+	if number == "1" {
+		if len(values) == 0 {
+			return nil
+		}
+		return typedValue(values[0], fieldType)
+	}
+	typed := []interface{}{}
+	for _, value := range values {
+		typed = append(typed, typedValue(value, fieldType))
+	}
+	return typed
+}
+
+// Convert a single value to its type, missing values and floats that aren't finite (NaN and Inf) become null
+// Values that can't be converted are kept as strings
+func typedValue(value string, fieldType string) interface{} {
+	if value == "." || value == "" {
+		return nil
+	}
+	switch strings.ToLower(fieldType) {
+	case "integer":
+		if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return number
+		}
+	case "float":
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			// JSON has no representation for NaN and Inf
+			if math.IsNaN(number) || math.IsInf(number, 0) {
+				return nil
+			}
+			return number
+		}
+	case "flag":
+		return isTruthy(value)
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package svync_api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypedValue(t *testing.T) {
+	tests := []struct {
+		value     string
+		fieldType string
+		want      interface{}
+	}{
+		{"10", "Integer", int64(10)},
+		{"-5", "integer", int64(-5)},
+		{"1.5", "Integer", "1.5"},
+		{"0.25", "Float", 0.25},
+		{"NaN", "Float", nil},
+		{"nan", "Float", nil},
+		{"Inf", "Float", nil},
+		{"-Inf", "Float", nil},
+		{".", "Float", nil},
+		{"", "String", nil},
+		{"DEL", "String", "DEL"},
+		{"abc", "Float", "abc"},
+		{"true", "Flag", true},
+		{"0", "Flag", false},
+	}
+	for _, test := range tests {
+		if got := typedValue(test.value, test.fieldType); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("typedValue(%s, %s) = %#v, want %#v", test.value, test.fieldType, got, test.want)
+		}
+	}
+}
+
+func TestVariantJsonl(t *testing.T) {
+	config := &Config{
+		Info: MapConfigInput{
+			"SVTYPE":    {Number: "1", Type: "String"},
+			"AF":        {Number: "A", Type: "Float"},
+			"IMPRECISE": {Number: "0", Type: "Flag"},
+		},
+		Format: MapConfigInput{},
+	}
+	variant := &Variant{
+		Chromosome: "chr1",
+		Pos:        100,
+		Id:         "del1",
+		Ref:        "N",
+		Alt:        "<DEL>",
+		Qual:       "NaN",
+		Filter:     "PASS",
+		Header:     newHeader(),
+		Info:       map[string][]string{"SVTYPE": {"DEL"}, "AF": {"Inf", "0.5"}},
+	}
+
+	want := `{"chrom":"chr1","pos":100,"id":"del1","ref":"N","alt":["<DEL>"],"qual":null,"filter":["PASS"],"info":{"AF":[null,0.5],"IMPRECISE":false,"SVTYPE":"DEL"}}`
+	if got := variant.jsonl(config); got != want {
+		t.Errorf("jsonl() =\n%s\nwant\n%s", got, want)
+	}
+}
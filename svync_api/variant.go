@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -100,3 +101,84 @@ func getInsLen(alt string, strand string, bracket string) int {
 	}
 	return len(alt[:strings.LastIndex(alt, bracket)])
 }
+
+// Get the keys used to look up the alts of a variant, ordered from the most to the least specific
+// Symbolic ALTs are split along their hierarchy (e.g. <INS:ME:ALU> => INS:ME:ALU, INS:ME, INS), followed by the SVTYPE
+func (variant *Variant) altKeys() []string {
+	keys := []string{}
+	if strings.HasPrefix(variant.Alt, "<") && strings.HasSuffix(variant.Alt, ">") {
+		keys = symbolicAltKeys(variant.Alt[1 : len(variant.Alt)-1])
+	}
+
+	if svtype, ok := variant.Info["SVTYPE"]; ok && len(svtype) > 0 && !slices.Contains(keys, svtype[0]) {
+		keys = append(keys, svtype[0])
+	}
+	return keys
+}
+
+// Split the ID of a symbolic ALT along its hierarchy (e.g. INS:ME:ALU => INS:ME:ALU, INS:ME, INS)
+func symbolicAltKeys(id string) []string {
+	keys := []string{}
+	levels := strings.Split(id, ":")
+	for i := len(levels); i > 0; i-- {
+		keys = append(keys, strings.Join(levels[:i], ":"))
+	}
+	return keys
+}
+
+// Get the first value of an INFO field as an integer
+func infoInt(variant *Variant, field string) (int64, error) {
+	values, ok := variant.Info[field]
+	if !ok || len(values) == 0 {
+		return 0, fmt.Errorf("the INFO field %s is missing", field)
+	}
+	return strconv.ParseInt(values[0], 10, 64)
+}
+
+// Get the first two values of an INFO field as an interval (e.g. CIPOS), defaults to 0,0
+func infoInterval(variant *Variant, field string) (int64, int64) {
+	values, ok := variant.Info[field]
+	if !ok {
+		return 0, 0
+	}
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+	if len(values) != 2 {
+		return 0, 0
+	}
+	start, errStart := strconv.ParseInt(values[0], 10, 64)
+	end, errEnd := strconv.ParseInt(values[1], 10, 64)
+	if errStart != nil || errEnd != nil {
+		return 0, 0
+	}
+	return start, end
+}
+
+// Check if an allele is a breakend (e.g. N[chr2:321682[ or a single breakend like N.)
+func isBreakendAllele(allele string) bool {
+	return strings.ContainsAny(allele, "[]") || (len(allele) > 1 && (strings.HasPrefix(allele, ".") || strings.HasSuffix(allele, ".")))
+}
+
+// Get the partner chromosome, position and the strands of a breakend from its ALT
+func (variant *Variant) breakendPartner() (string, int64, string, string, bool) {
+	groups := breakendAltRegex.FindStringSubmatch(variant.Alt)
+	if len(groups) == 0 {
+		return "", 0, "", "", false
+	}
+	pos, err := strconv.ParseInt(groups[3], 10, 64)
+	if err != nil {
+		return "", 0, "", "", false
+	}
+
+	// t[p[ and t]p] join after the base at POS, ]p]t and [p[t join before it
+	strand1 := "+"
+	if strings.HasPrefix(variant.Alt, "[") || strings.HasPrefix(variant.Alt, "]") {
+		strand1 = "-"
+	}
+	strand2 := "+"
+	if groups[1] == "[" {
+		strand2 = "-"
+	}
+	return groups[2], pos, strand1, strand2, true
+}
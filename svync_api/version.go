@@ -0,0 +1,235 @@
+package svync_api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// A struct representing the Number and Type of a reserved INFO or FORMAT field
+type reservedField struct {
+	Number string
+	Type   string
+}
+
+// The reserved INFO fields of structural variants in VCF 4.2 and 4.3
+var reservedInfoFields = map[string]reservedField{
+	"SVTYPE":    {"1", "String"},
+	"SVLEN":     {".", "Integer"},
+	"END":       {"1", "Integer"},
+	"IMPRECISE": {"0", "Flag"},
+	"NOVEL":     {"0", "Flag"},
+	"CIPOS":     {"2", "Integer"},
+	"CIEND":     {"2", "Integer"},
+	"HOMLEN":    {".", "Integer"},
+	"HOMSEQ":    {".", "String"},
+	"BKPTID":    {".", "String"},
+	"MEINFO":    {"4", "String"},
+	"METRANS":   {"4", "String"},
+	"DGVID":     {"1", "String"},
+	"DBVARID":   {"1", "String"},
+	"DBRIPID":   {"1", "String"},
+	"MATEID":    {".", "String"},
+	"PARID":     {"1", "String"},
+	"EVENT":     {"1", "String"},
+	"CILEN":     {"2", "Integer"},
+	"DPADJ":     {".", "Integer"},
+	"CN":        {"1", "Integer"},
+	"CNADJ":     {".", "Integer"},
+	"CICN":      {"2", "Integer"},
+	"CICNADJ":   {".", "Integer"},
+}
+
+// The reserved INFO fields of structural variants in VCF 4.4
+var reservedInfoFields44 = map[string]reservedField{
+	"SVTYPE":    {"1", "String"},
+	"SVLEN":     {"A", "Integer"},
+	"END":       {"1", "Integer"},
+	"IMPRECISE": {"0", "Flag"},
+	"NOVEL":     {"0", "Flag"},
+	"CIPOS":     {".", "Integer"},
+	"CIEND":     {".", "Integer"},
+	"HOMLEN":    {"A", "Integer"},
+	"HOMSEQ":    {"A", "String"},
+	"BKPTID":    {".", "String"},
+	"MEINFO":    {".", "String"},
+	"METRANS":   {".", "String"},
+	"DGVID":     {"A", "String"},
+	"DBVARID":   {"A", "String"},
+	"DBRIPID":   {"A", "String"},
+	"MATEID":    {"A", "String"},
+	"PARID":     {"A", "String"},
+	"EVENT":     {"A", "String"},
+	"EVENTTYPE": {"A", "String"},
+	"CILEN":     {".", "Integer"},
+	"DPADJ":     {".", "Integer"},
+	"CN":        {"A", "Float"},
+	"CICN":      {".", "Float"},
+	"SVCLAIM":   {"A", "String"},
+}
+
+// The reserved FORMAT fields of structural variants in VCF 4.2 and 4.3
+var reservedFormatFields = map[string]reservedField{
+	"GT":   {"1", "String"},
+	"FT":   {"1", "String"},
+	"GQ":   {"1", "Integer"},
+	"DP":   {"1", "Integer"},
+	"CN":   {"1", "Integer"},
+	"CNQ":  {"1", "Float"},
+	"CNL":  {"G", "Float"},
+	"NQ":   {"1", "Integer"},
+	"HAP":  {"1", "Integer"},
+	"AHAP": {"1", "Integer"},
+}
+
+// The reserved FORMAT fields of structural variants in VCF 4.4
+var reservedFormatFields44 = map[string]reservedField{
+	"GT":   {"1", "String"},
+	"FT":   {"1", "String"},
+	"GQ":   {"1", "Integer"},
+	"DP":   {"1", "Integer"},
+	"CN":   {"1", "Float"},
+	"CICN": {"2", "Float"},
+	"CNQ":  {"1", "Float"},
+	"CNL":  {"G", "Float"},
+	"CNP":  {"G", "Float"},
+	"NQ":   {"1", "Integer"},
+	"HAP":  {"1", "Integer"},
+	"AHAP": {"1", "Integer"},
+}
+
+// Validate the --vcf-version argument and return the version
+func vcfVersion(Cctx *cli.Context) string {
+	logger := newLogger()
+
+	version := strings.TrimPrefix(strings.ToLower(Cctx.String("vcf-version")), "vcfv")
+	switch version {
+	case "4.2", "4.3", "4.4":
+		return version
+	}
+	logger.Fatalf("The VCF version '%s' is not supported, use '4.2', '4.3' or '4.4'", Cctx.String("vcf-version"))
+	return ""
+}
+
+// Check if the VCF version is at least the minimum version (e.g. 4.3)
+func versionAtLeast(version string, minimum string) bool {
+	versionNumber, err := strconv.ParseFloat(version, 64)
+	if err != nil {
+		return false
+	}
+	minimumNumber, _ := strconv.ParseFloat(minimum, 64)
+	return versionNumber >= minimumNumber
+}
+
+// Fail on INFO and FORMAT fields in the config that don't match the reserved fields of the VCF version
+func (config *Config) checkReservedFields() {
+	logger := newLogger()
+
+	infoFields, formatFields := reservedInfoFields, reservedFormatFields
+	if config.vcfVersion == "4.4" {
+		infoFields, formatFields = reservedInfoFields44, reservedFormatFields44
+	}
+
+	check := func(section string, name string, input ConfigInput, reserved map[string]reservedField) {
+		field, ok := reserved[name]
+		if !ok {
+			return
+		}
+		if !reservedNumberMatches(input.Number, field.Number) || !strings.EqualFold(input.Type, field.Type) {
+			logger.Fatalf("The %s field %s is reserved in VCF %s with Number=%s and Type=%s, but is defined with Number=%s and Type=%s", section, name, config.vcfVersion, field.Number, field.Type, input.Number, input.Type)
+		}
+	}
+	for _, name := range sortedKeys(config.Info) {
+		check("INFO", name, config.Info[name], infoFields)
+	}
+	for _, name := range sortedKeys(config.Format) {
+		check("FORMAT", name, config.Format[name], formatFields)
+	}
+}
+
+// Check if the Number of a field is valid for the Number of the reserved field
+// Every variant has a single ALT, so Number=1 is valid for fields with one value per ALT
+// and a fixed number of values is valid for fields with a varying number of values
+func reservedNumberMatches(number string, reserved string) bool {
+	if number == reserved {
+		return true
+	}
+	if number == "1" && reserved == "A" {
+		return true
+	}
+	_, err := strconv.Atoi(number)
+	return reserved == "." && err == nil
+}
+
+// Apply the conventions of the VCF version to a standardized variant
+// VCF 4.4 uses the absolute length of the variant as SVLEN
+func (config *Config) applyVersionConventions(variant *Variant) {
+	if config.vcfVersion != "4.4" {
+		return
+	}
+	for index, value := range variant.Info["SVLEN"] {
+		variant.Info["SVLEN"][index] = strings.TrimPrefix(value, "-")
+	}
+}
+
+// Join the values of an INFO or FORMAT field
+// The characters with a special meaning are percent-encoded in VCF 4.3 and later
+func (config *Config) encodeValues(values []string, special string) string {
+	if !versionAtLeast(config.vcfVersion, "4.3") {
+		return strings.Join(values, ",")
+	}
+	encoded := []string{}
+	for _, value := range values {
+		encoded = append(encoded, percentEncode(value, special))
+	}
+	return strings.Join(encoded, ",")
+}
+
+// Percent-encode the characters with a special meaning in VCF 4.3 and later
+func percentEncode(value string, special string) string {
+	if !strings.ContainsAny(value, special+"%\t\r\n") {
+		return value
+	}
+	encoded := strings.Builder{}
+	for _, letter := range value {
+		if strings.ContainsRune(special+"%\t\r\n", letter) {
+			encoded.WriteString(fmt.Sprintf("%%%02X", letter))
+			continue
+		}
+		encoded.WriteRune(letter)
+	}
+	return encoded.String()
+}
+
+// Decode the percent-encoded values of INFO and FORMAT fields in VCF 4.3 and later
+func (header *Header) decodeValues(values []string) []string {
+	if !versionAtLeast(header.version, "4.3") {
+		return values
+	}
+	for index, value := range values {
+		values[index] = percentDecode(value)
+	}
+	return values
+}
+
+// Decode the percent-encoded characters of VCF 4.3 and later
+// Percent signs that aren't followed by two hexadecimal digits are kept
+func percentDecode(value string) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+	decoded := strings.Builder{}
+	for index := 0; index < len(value); index++ {
+		if value[index] == '%' && index+2 < len(value) {
+			if letter, err := strconv.ParseUint(value[index+1:index+3], 16, 8); err == nil {
+				decoded.WriteByte(byte(letter))
+				index += 2
+				continue
+			}
+		}
+		decoded.WriteByte(value[index])
+	}
+	return decoded.String()
+}
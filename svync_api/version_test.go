@@ -0,0 +1,55 @@
+package svync_api
+
+import "testing"
+
+func TestReservedNumberMatches(t *testing.T) {
+	tests := []struct {
+		number   string
+		reserved string
+		want     bool
+	}{
+		{"1", "1", true},
+		{"1", "A", true},
+		{"A", "A", true},
+		{"2", "A", false},
+		{"2", ".", true},
+		{"1", ".", true},
+		{".", ".", true},
+		{"A", ".", false},
+		{".", "1", false},
+		{"0", "1", false},
+	}
+	for _, test := range tests {
+		if got := reservedNumberMatches(test.number, test.reserved); got != test.want {
+			t.Errorf("reservedNumberMatches(%s, %s) = %v, want %v", test.number, test.reserved, got, test.want)
+		}
+	}
+}
+
+func TestCheckReservedFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		field   ConfigInput
+		fails   bool
+	}{
+		{"integer copy number in VCF 4.2", "4.2", ConfigInput{Number: "1", Type: "Integer"}, false},
+		{"integer copy number in VCF 4.4", "4.4", ConfigInput{Number: "1", Type: "Integer"}, true},
+		{"float copy number in VCF 4.4", "4.4", ConfigInput{Number: "A", Type: "Float"}, false},
+		{"lowercase type", "4.4", ConfigInput{Number: "1", Type: "float"}, false},
+		{"wrong number", "4.2", ConfigInput{Number: "2", Type: "Integer"}, true},
+	}
+	for _, test := range tests {
+		config := &Config{vcfVersion: test.version, Info: MapConfigInput{"CN": test.field}, Format: MapConfigInput{}}
+		failed := func() (failed bool) {
+			defer func() {
+				_, failed = recover().(fatalError)
+			}()
+			config.checkReservedFields()
+			return false
+		}()
+		if failed != test.fails {
+			t.Errorf("%s: checkReservedFields() failed = %v, want %v", test.name, failed, test.fails)
+		}
+	}
+}